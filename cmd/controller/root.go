@@ -9,7 +9,9 @@ import (
 
 	"github.com/ihcsim/k8s-dra/cmd/flags"
 	draclientset "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned"
+	drainformers "github.com/ihcsim/k8s-dra/pkg/apis/informers/externalversions"
 	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu"
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/migration"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -17,6 +19,7 @@ import (
 	"k8s.io/client-go/informers"
 	coreclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/dynamic-resource-allocation/controller"
 )
@@ -56,8 +59,18 @@ func run(ctx context.Context) error {
 		pprofPath   = "/debug/pprof/"
 
 		namespace = viper.GetString("namespace")
+		holdTTL   = viper.GetDuration("hold-ttl")
+
+		orphanGCPeriod = viper.GetDuration("orphan-gc-period")
+		nodeGCPeriod   = viper.GetDuration("node-gc-period")
+
+		structuredParameters = viper.GetBool("structured-parameters")
 	)
 
+	if holdTTL <= 0 {
+		return fmt.Errorf("hold TTL must be positive, got %s", holdTTL)
+	}
+
 	go func() {
 		s := http.NewServeMux()
 		s.HandleFunc(pprofPath, pprof.Index)
@@ -78,6 +91,10 @@ func run(ctx context.Context) error {
 		Int("workers", workerCount).
 		Float64("qps", qps).
 		Float64("burst", burst).
+		Dur("holdTTL", holdTTL).
+		Dur("orphanGCPeriod", orphanGCPeriod).
+		Dur("nodeGCPeriod", nodeGCPeriod).
+		Bool("structuredParameters", structuredParameters).
 		Str("metrics", fmt.Sprintf("/%s:%d", metricsPath, metricsPort)).
 		Str("pprof", fmt.Sprintf("%s:%d", pprofPath, pprofPort)).
 		Send()
@@ -92,20 +109,76 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	// the NodeGPUSlices stored in v1alpha1 must be migrated before the
+	// controller reads them through the v1alpha2 API
+	if err := migration.MigrateNodeGPUSlices(ctx, draClientSets, namespace, log.Logger); err != nil {
+		return err
+	}
+
 	var (
-		resync          = time.Minute * 10
-		informerFactory = informers.NewSharedInformerFactory(coreClientSets, resync)
+		resync             = time.Minute * 10
+		informerFactory    = informers.NewSharedInformerFactory(coreClientSets, resync)
+		draInformerFactory = drainformers.NewSharedInformerFactoryWithOptions(draClientSets, resync, drainformers.WithNamespace(namespace))
+		nodeDevices        = draInformerFactory.Gpu().V1alpha2().NodeGPUSlices()
+		nodes              = informerFactory.Core().V1().Nodes()
 	)
-	informerFactory.Start(ctx.Done())
 
+	// the informers must be registered with the factories before they're
+	// started
+	nodeDevicesSynced := nodeDevices.Informer().HasSynced
+	nodesSynced := nodes.Informer().HasSynced
 	driverLog := log.Logger.With().Str("namespace", namespace).Logger()
-	driver, err := gpu.NewDriver(draClientSets, namespace, driverLog)
+
+	// GPURequirements are namespaced with their claims, so the structured
+	// parameters controller watches all the namespaces
+	paramsInformerFactory := drainformers.NewSharedInformerFactory(draClientSets, resync)
+	if structuredParameters {
+		paramsCtrl, err := gpu.NewParametersController(
+			coreClientSets,
+			paramsInformerFactory.Gpu().V1alpha1().GPURequirements(),
+			paramsInformerFactory.Gpu().V1alpha1().GPUClassParameters(),
+			informerFactory.Resource().V1alpha2().ResourceClaimParameters(),
+			informerFactory.Resource().V1alpha2().ResourceClassParameters(),
+			informerFactory.Resource().V1alpha2().ResourceClaims(),
+			informerFactory.Resource().V1alpha2().ResourceClasses(),
+			namespace,
+			driverLog)
+		if err != nil {
+			return err
+		}
+		go paramsCtrl.Run(ctx, workerCount)
+	}
+
+	// the DRA controller registers the informers of its claims, classes and
+	// pod scheduling contexts with the core factory
+	driver, err := gpu.NewDriver(draClientSets, nodeDevices.Lister(), namespace, driverLog)
 	if err != nil {
 		return err
 	}
+	ctrl := controller.New(ctx, driver.GetName(), driver, coreClientSets, informerFactory)
+
+	informerFactory.Start(ctx.Done())
+	draInformerFactory.Start(ctx.Done())
+	paramsInformerFactory.Start(ctx.Done())
+
+	log.Info().Msg("waiting for NodeGPUSlices cache to sync")
+	if !cache.WaitForCacheSync(ctx.Done(), nodeDevicesSynced, nodesSynced) {
+		return fmt.Errorf("failed to sync NodeGPUSlices cache")
+	}
+
+	if _, err := nodeDevices.Informer().AddEventHandler(driver.AllocatedCountHandler(ctx)); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := driver.ReapExpiredHolds(ctx, holdTTL); err != nil {
+			log.Error().Err(err).Msg("stopped reaping expired holds")
+		}
+	}()
+	go driver.CollectOrphanedAllocations(ctx, coreClientSets, orphanGCPeriod)
+	go driver.SweepDeletedNodes(ctx, coreClientSets, nodes.Lister(), nodeGCPeriod)
 
 	log.Info().Str("driver", driver.GetName()).Msg("starting driver controller")
-	ctrl := controller.New(ctx, driver.GetName(), driver, coreClientSets, informerFactory)
 	ctrl.Run(workerCount)
 	return nil
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ihcsim/k8s-dra/cmd/flags"
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const webhookPath = "/validate"
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "webhook serves the validating admission webhook of the GPU CRDs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebhook(cmd.Context())
+	},
+}
+
+func init() {
+	webhookCmd.Flags().AddFlagSet(flags.NewWebhookFlags())
+	for _, flag := range []string{"tls-cert-file", "tls-key-file"} {
+		if err := webhookCmd.MarkFlagRequired(flag); err != nil {
+			log.Fatal().Err(err).Msg("failed to mark flags as required")
+		}
+	}
+
+	if err := viper.BindPFlags(webhookCmd.Flags()); err != nil {
+		log.Fatal().Err(err).Msg("failed to bind flags")
+	}
+
+	rootCmd.AddCommand(webhookCmd)
+}
+
+func runWebhook(ctx context.Context) error {
+	var (
+		port     = viper.GetInt("webhook-port")
+		certFile = viper.GetString("tls-cert-file")
+		keyFile  = viper.GetString("tls-key-file")
+	)
+
+	webhook, err := gpu.NewWebhook(log.Logger.With().Str("component", "webhook").Logger())
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(webhookPath, webhook)
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("failed to shut down webhook server")
+		}
+	}()
+
+	log.Info().
+		Int("port", port).
+		Str("path", webhookPath).
+		Msg("starting validating admission webhook")
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package flags
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -20,6 +22,18 @@ func NewControllerFlags() *pflag.FlagSet {
 	flags.Int("metrics-port", 9001, "HTTP port to expose metrics")
 	flags.String("metrics-path", "metrics", "HTTP path to expose metrics")
 	flags.Int("pprof-port", 9002, "HTTP port to expose pprof endpoints")
+	flags.Duration("hold-ttl", time.Minute, "Duration after which temporary holds on unallocated devices are released")
+	flags.Duration("orphan-gc-period", 5*time.Minute, "Period of the garbage collection of the device allocations of deleted claims")
+	flags.Duration("node-gc-period", 5*time.Minute, "Period of the garbage collection of the NodeGPUSlices of deleted nodes")
+	flags.Bool("structured-parameters", false, "Generate ResourceClaimParameters and ResourceClassParameters from the GPU parameters, for allocation by the scheduler")
+	return flags
+}
+
+func NewWebhookFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("webhook", pflag.ExitOnError)
+	flags.Int("webhook-port", 9443, "HTTPS port to serve the validating admission webhook")
+	flags.String("tls-cert-file", "", "Path to the TLS certificate file of the webhook server")
+	flags.String("tls-key-file", "", "Path to the TLS private key file of the webhook server")
 	return flags
 }
 
@@ -28,5 +42,18 @@ func NewPluginFlags() *pflag.FlagSet {
 	flags.String("cdi-root", "/etc/cdi", "Absolute path to the directory where CDI files will be generated")
 	flags.String("namespace", "k8s-dra", "Namespace where the kubelet plugin watches for DeviceAllocation CRDs")
 	flags.Int("max-available-gpu", 4, "Maximum number of GPUs available on the node")
+	flags.String("discovery", "cdi", "Device discovery backend, one of cdi, sysfs or static")
+	flags.String("sysfs-root", "/sys", "Root of the sysfs tree scanned by the sysfs discovery backend")
+	flags.String("inventory-file", "", "Path to the YAML inventory file read by the static discovery backend")
+	flags.Int("time-slicing-replicas", 0, "Number of time-sliced replicas advertised for each GPU to the scheduler, or 0 to advertise whole GPUs")
+	flags.Duration("health-check-period", 30*time.Second, "Period of the device health checks")
+	flags.Duration("health-check-timeout", 10*time.Second, "Timeout of the exec device health check")
+	flags.String("health-file-dir", "", "Directory where a file named after a device UUID marks the device as unhealthy")
+	flags.String("health-exec-command", "", "Command run for every device with its UUID as last argument, which marks the device as unhealthy if it fails")
+	flags.Bool("synthetic-gpus", false, "Generate the CDI specs of max-available-gpu synthetic GPUs in the CDI root at startup, for environments without GPUs")
+	flags.StringSlice("synthetic-products", []string{"nvidia/A100", "nvidia/H100"}, "Mix of vendor/product pairs assigned to the synthetic GPUs in a round-robin fashion")
+	flags.StringSlice("synthetic-memory", []string{"40Gi", "80Gi"}, "Mix of memory sizes assigned to the synthetic GPUs in a round-robin fashion")
+	flags.Int("synthetic-numa-nodes", 2, "Number of NUMA nodes the synthetic GPUs are spread across, or 0 for no NUMA topology")
+	flags.Int("synthetic-interconnect-group-size", 2, "Number of synthetic GPUs in each interconnect group, or 0 for no interconnect groups")
 	return flags
 }
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/ihcsim/k8s-dra/cmd/flags"
@@ -14,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	coreclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/dynamic-resource-allocation/kubeletplugin"
@@ -62,8 +65,25 @@ func run(ctx context.Context) error {
 		cdiRoot    = viper.GetString("cdi-root")
 		namespace  = viper.GetString("namespace")
 		nodeName   = viper.GetString("node-name")
+
+		discovery           = viper.GetString("discovery")
+		syntheticGPUs       = viper.GetBool("synthetic-gpus")
+		timeSlicingReplicas = viper.GetInt("time-slicing-replicas")
 	)
 
+	if timeSlicingReplicas < 0 {
+		return fmt.Errorf("invalid number of time-sliced replicas: %d", timeSlicingReplicas)
+	}
+
+	discoverer, err := newDiscoverer(discovery)
+	if err != nil {
+		return err
+	}
+
+	if syntheticGPUs && discovery != gpukubeletplugin.DiscoveryCDI {
+		return fmt.Errorf("synthetic GPUs require the %s discovery backend", gpukubeletplugin.DiscoveryCDI)
+	}
+
 	if err := os.MkdirAll(pluginPath, 0750); err != nil {
 		return err
 	}
@@ -72,17 +92,56 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	if syntheticGPUs {
+		synthetic := gpukubeletplugin.SyntheticGPUs{
+			Count:                 viper.GetInt("max-available-gpu"),
+			Products:              viper.GetStringSlice("synthetic-products"),
+			Memory:                viper.GetStringSlice("synthetic-memory"),
+			NUMANodes:             viper.GetInt("synthetic-numa-nodes"),
+			InterconnectGroupSize: viper.GetInt("synthetic-interconnect-group-size"),
+		}
+		gpus, err := gpukubeletplugin.GenerateSyntheticGPUs(cdiRoot, nodeName, synthetic)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("generated %d synthetic GPUs in %s", len(gpus), cdiRoot)
+	}
+
+	coreClientSets, err := coreClientSets(kubeconfig)
+	if err != nil {
+		return err
+	}
+
 	draClientSets, err := draClientSets(kubeconfig)
 	if err != nil {
 		return err
 	}
 
 	log.Info().Msgf("starting DRA node server...")
-	nodeServer, err := gpukubeletplugin.NewNodeServer(ctx, draClientSets, cdiRoot, namespace, nodeName, log.Logger)
+	nodeServer, err := gpukubeletplugin.NewNodeServer(ctx, coreClientSets, draClientSets, discoverer, timeSlicingReplicas, cdiRoot, namespace, nodeName, log.Logger)
 	if err != nil {
 		return err
 	}
 
+	// the inventory only changes with the CDI specs when they're discovered
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if discovery == gpukubeletplugin.DiscoveryCDI {
+		go func() {
+			if err := nodeServer.WatchCDIRoot(watchCtx, cdiRoot); err != nil {
+				log.Error().Err(err).Msg("stopped watching CDI root")
+			}
+		}()
+	}
+
+	if checkers := healthCheckers(); len(checkers) > 0 {
+		go func() {
+			if err := nodeServer.MonitorHealth(watchCtx, checkers, viper.GetDuration("health-check-period")); err != nil {
+				log.Error().Err(err).Msg("stopped monitoring device health")
+			}
+		}()
+	}
+
 	p, err := kubeletplugin.Start(
 		nodeServer,
 		kubeletplugin.DriverName(driverName),
@@ -102,6 +161,35 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+func newDiscoverer(discovery string) (gpukubeletplugin.Discoverer, error) {
+	switch discovery {
+	case gpukubeletplugin.DiscoveryCDI:
+		return gpukubeletplugin.NewCDIDiscoverer(), nil
+	case gpukubeletplugin.DiscoverySysfs:
+		return gpukubeletplugin.NewSysfsDiscoverer(viper.GetString("sysfs-root")), nil
+	case gpukubeletplugin.DiscoveryStatic:
+		inventoryFile := viper.GetString("inventory-file")
+		if inventoryFile == "" {
+			return nil, fmt.Errorf("the %s discovery backend requires an inventory file", gpukubeletplugin.DiscoveryStatic)
+		}
+		return gpukubeletplugin.NewStaticDiscoverer(inventoryFile), nil
+	}
+
+	return nil, fmt.Errorf("unsupported discovery backend %q", discovery)
+}
+
+func healthCheckers() []gpukubeletplugin.HealthChecker {
+	var checkers []gpukubeletplugin.HealthChecker
+	if dir := viper.GetString("health-file-dir"); dir != "" {
+		checkers = append(checkers, gpukubeletplugin.NewFileHealthChecker(dir))
+	}
+
+	if command := strings.Fields(viper.GetString("health-exec-command")); len(command) > 0 {
+		checkers = append(checkers, gpukubeletplugin.NewExecHealthChecker(command, viper.GetDuration("health-check-timeout")))
+	}
+	return checkers
+}
+
 func kubeConfig(kubeconfigPath string) (*rest.Config, error) {
 	if kubeconfigPath != "" {
 		return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
@@ -109,6 +197,15 @@ func kubeConfig(kubeconfigPath string) (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
+func coreClientSets(kubeconfigPath string) (coreclientset.Interface, error) {
+	kubecfg, err := kubeConfig(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return coreclientset.NewForConfig(kubecfg)
+}
+
 func draClientSets(kubeconfigPath string) (draclientset.Interface, error) {
 	kubecfg, err := kubeConfig(kubeconfigPath)
 	if err != nil {
@@ -21,14 +21,16 @@ package v1alpha1
 import (
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DeviceAllocationApplyConfiguration represents an declarative configuration of the DeviceAllocation type for use
 // with apply.
 type DeviceAllocationApplyConfiguration struct {
-	Claim  *v1.TypedLocalObjectReference      `json:"claim,omitempty"`
-	Device *GPUDeviceApplyConfiguration       `json:"devices,omitempty"`
-	State  *gpuv1alpha1.DeviceAllocationState `json:"state,omitempty"`
+	Claim         *v1.TypedLocalObjectReference      `json:"claim,omitempty"`
+	Device        *GPUDeviceApplyConfiguration       `json:"devices,omitempty"`
+	State         *gpuv1alpha1.DeviceAllocationState `json:"state,omitempty"`
+	HoldTimestamp *metav1.Time                       `json:"holdTimestamp,omitempty"`
 }
 
 // DeviceAllocationApplyConfiguration constructs an declarative configuration of the DeviceAllocation type for use with
@@ -60,3 +62,11 @@ func (b *DeviceAllocationApplyConfiguration) WithState(value gpuv1alpha1.DeviceA
 	b.State = &value
 	return b
 }
+
+// WithHoldTimestamp sets the HoldTimestamp field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the HoldTimestamp field is set to the value of the last call.
+func (b *DeviceAllocationApplyConfiguration) WithHoldTimestamp(value metav1.Time) *DeviceAllocationApplyConfiguration {
+	b.HoldTimestamp = &value
+	return b
+}
@@ -21,8 +21,9 @@ package v1alpha1
 // DeviceSelectorApplyConfiguration represents an declarative configuration of the DeviceSelector type for use
 // with apply.
 type DeviceSelectorApplyConfiguration struct {
-	Name   *string `json:"name,omitempty"`
-	Vendor *string `json:"vendor,omitempty"`
+	Name       *string `json:"name,omitempty"`
+	Vendor     *string `json:"vendor,omitempty"`
+	Expression *string `json:"expression,omitempty"`
 }
 
 // DeviceSelectorApplyConfiguration constructs an declarative configuration of the DeviceSelector type for use with
@@ -46,3 +47,11 @@ func (b *DeviceSelectorApplyConfiguration) WithVendor(value string) *DeviceSelec
 	b.Vendor = &value
 	return b
 }
+
+// WithExpression sets the Expression field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Expression field is set to the value of the last call.
+func (b *DeviceSelectorApplyConfiguration) WithExpression(value string) *DeviceSelectorApplyConfiguration {
+	b.Expression = &value
+	return b
+}
@@ -38,7 +38,7 @@ func GPUClassParameters(name string) *GPUClassParametersApplyConfiguration {
 	b := &GPUClassParametersApplyConfiguration{}
 	b.WithName(name)
 	b.WithKind("GPUClassParameters")
-	b.WithAPIVersion("gpu/v1alpha1")
+	b.WithAPIVersion("dra.resources.ihcsim/v1alpha1")
 	return b
 }
 
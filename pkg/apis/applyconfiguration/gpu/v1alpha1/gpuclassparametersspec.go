@@ -18,10 +18,17 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+)
+
 // GPUClassParametersSpecApplyConfiguration represents an declarative configuration of the GPUClassParametersSpec type for use
 // with apply.
 type GPUClassParametersSpecApplyConfiguration struct {
-	DeviceSelector []DeviceSelectorApplyConfiguration `json:"deviceSelector,omitempty"`
+	DeviceSelector    []DeviceSelectorApplyConfiguration `json:"deviceSelector,omitempty"`
+	DefaultAccessMode *gpuv1alpha1.AccessMode            `json:"defaultAccessMode,omitempty"`
+	MaxSharers        *int                               `json:"maxSharers,omitempty"`
+	TimeSlicing       *TimeSlicingApplyConfiguration     `json:"timeSlicing,omitempty"`
 }
 
 // GPUClassParametersSpecApplyConfiguration constructs an declarative configuration of the GPUClassParametersSpec type for use with
@@ -42,3 +49,27 @@ func (b *GPUClassParametersSpecApplyConfiguration) WithDeviceSelector(values ...
 	}
 	return b
 }
+
+// WithDefaultAccessMode sets the DefaultAccessMode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DefaultAccessMode field is set to the value of the last call.
+func (b *GPUClassParametersSpecApplyConfiguration) WithDefaultAccessMode(value gpuv1alpha1.AccessMode) *GPUClassParametersSpecApplyConfiguration {
+	b.DefaultAccessMode = &value
+	return b
+}
+
+// WithMaxSharers sets the MaxSharers field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the MaxSharers field is set to the value of the last call.
+func (b *GPUClassParametersSpecApplyConfiguration) WithMaxSharers(value int) *GPUClassParametersSpecApplyConfiguration {
+	b.MaxSharers = &value
+	return b
+}
+
+// WithTimeSlicing sets the TimeSlicing field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TimeSlicing field is set to the value of the last call.
+func (b *GPUClassParametersSpecApplyConfiguration) WithTimeSlicing(value *TimeSlicingApplyConfiguration) *GPUClassParametersSpecApplyConfiguration {
+	b.TimeSlicing = value
+	return b
+}
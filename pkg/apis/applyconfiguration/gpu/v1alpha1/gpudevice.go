@@ -18,12 +18,17 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
 // GPUDeviceApplyConfiguration represents an declarative configuration of the GPUDevice type for use
 // with apply.
 type GPUDeviceApplyConfiguration struct {
-	UUID        *string `json:"uuid,omitempty"`
-	ProductName *string `json:"productName,omitempty"`
-	Vendor      *string `json:"vendor,omitempty"`
+	UUID        *string            `json:"uuid,omitempty"`
+	ProductName *string            `json:"productName,omitempty"`
+	Vendor      *string            `json:"vendor,omitempty"`
+	Memory      *resource.Quantity `json:"memory,omitempty"`
 }
 
 // GPUDeviceApplyConfiguration constructs an declarative configuration of the GPUDevice type for use with
@@ -55,3 +60,11 @@ func (b *GPUDeviceApplyConfiguration) WithVendor(value string) *GPUDeviceApplyCo
 	b.Vendor = &value
 	return b
 }
+
+// WithMemory sets the Memory field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Memory field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithMemory(value resource.Quantity) *GPUDeviceApplyConfiguration {
+	b.Memory = &value
+	return b
+}
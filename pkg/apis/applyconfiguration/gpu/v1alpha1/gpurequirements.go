@@ -39,7 +39,7 @@ func GPURequirements(name, namespace string) *GPURequirementsApplyConfiguration
 	b.WithName(name)
 	b.WithNamespace(namespace)
 	b.WithKind("GPURequirements")
-	b.WithAPIVersion("gpu/v1alpha1")
+	b.WithAPIVersion("dra.resources.ihcsim/v1alpha1")
 	return b
 }
 
@@ -18,10 +18,20 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
 // GPURequirementsSpecApplyConfiguration represents an declarative configuration of the GPURequirementsSpec type for use
 // with apply.
 type GPURequirementsSpecApplyConfiguration struct {
-	Count *int `json:"count,omitempty"`
+	Count            *int                        `json:"count,omitempty"`
+	Memory           *resource.Quantity          `json:"memory,omitempty"`
+	Topology         *gpuv1alpha1.TopologyPolicy `json:"topology,omitempty"`
+	AccessMode       *gpuv1alpha1.AccessMode     `json:"accessMode,omitempty"`
+	Sharing          *gpuv1alpha1.SharingPolicy  `json:"sharing,omitempty"`
+	PartitionProfile *string                     `json:"partitionProfile,omitempty"`
 }
 
 // GPURequirementsSpecApplyConfiguration constructs an declarative configuration of the GPURequirementsSpec type for use with
@@ -37,3 +47,43 @@ func (b *GPURequirementsSpecApplyConfiguration) WithCount(value int) *GPURequire
 	b.Count = &value
 	return b
 }
+
+// WithMemory sets the Memory field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Memory field is set to the value of the last call.
+func (b *GPURequirementsSpecApplyConfiguration) WithMemory(value resource.Quantity) *GPURequirementsSpecApplyConfiguration {
+	b.Memory = &value
+	return b
+}
+
+// WithTopology sets the Topology field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Topology field is set to the value of the last call.
+func (b *GPURequirementsSpecApplyConfiguration) WithTopology(value gpuv1alpha1.TopologyPolicy) *GPURequirementsSpecApplyConfiguration {
+	b.Topology = &value
+	return b
+}
+
+// WithAccessMode sets the AccessMode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the AccessMode field is set to the value of the last call.
+func (b *GPURequirementsSpecApplyConfiguration) WithAccessMode(value gpuv1alpha1.AccessMode) *GPURequirementsSpecApplyConfiguration {
+	b.AccessMode = &value
+	return b
+}
+
+// WithSharing sets the Sharing field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Sharing field is set to the value of the last call.
+func (b *GPURequirementsSpecApplyConfiguration) WithSharing(value gpuv1alpha1.SharingPolicy) *GPURequirementsSpecApplyConfiguration {
+	b.Sharing = &value
+	return b
+}
+
+// WithPartitionProfile sets the PartitionProfile field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the PartitionProfile field is set to the value of the last call.
+func (b *GPURequirementsSpecApplyConfiguration) WithPartitionProfile(value string) *GPURequirementsSpecApplyConfiguration {
+	b.PartitionProfile = &value
+	return b
+}
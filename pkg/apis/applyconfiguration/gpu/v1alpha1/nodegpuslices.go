@@ -42,7 +42,7 @@ func NodeGPUSlices(name, namespace string) *NodeGPUSlicesApplyConfiguration {
 	b.WithName(name)
 	b.WithNamespace(namespace)
 	b.WithKind("NodeGPUSlices")
-	b.WithAPIVersion("gpu/v1alpha1")
+	b.WithAPIVersion("dra.resources.ihcsim/v1alpha1")
 	return b
 }
 
@@ -0,0 +1,62 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeviceHealthApplyConfiguration represents an declarative configuration of the DeviceHealth type for use
+// with apply.
+type DeviceHealthApplyConfiguration struct {
+	Status             *gpuv1alpha2.DeviceHealthStatus `json:"status,omitempty"`
+	Message            *string                         `json:"message,omitempty"`
+	LastTransitionTime *v1.Time                        `json:"lastTransitionTime,omitempty"`
+}
+
+// DeviceHealthApplyConfiguration constructs an declarative configuration of the DeviceHealth type for use with
+// apply.
+func DeviceHealth() *DeviceHealthApplyConfiguration {
+	return &DeviceHealthApplyConfiguration{}
+}
+
+// WithStatus sets the Status field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Status field is set to the value of the last call.
+func (b *DeviceHealthApplyConfiguration) WithStatus(value gpuv1alpha2.DeviceHealthStatus) *DeviceHealthApplyConfiguration {
+	b.Status = &value
+	return b
+}
+
+// WithMessage sets the Message field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Message field is set to the value of the last call.
+func (b *DeviceHealthApplyConfiguration) WithMessage(value string) *DeviceHealthApplyConfiguration {
+	b.Message = &value
+	return b
+}
+
+// WithLastTransitionTime sets the LastTransitionTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastTransitionTime field is set to the value of the last call.
+func (b *DeviceHealthApplyConfiguration) WithLastTransitionTime(value v1.Time) *DeviceHealthApplyConfiguration {
+	b.LastTransitionTime = &value
+	return b
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// GPUDeviceApplyConfiguration represents an declarative configuration of the GPUDevice type for use
+// with apply.
+type GPUDeviceApplyConfiguration struct {
+	UUID        *string                         `json:"uuid,omitempty"`
+	ProductName *string                         `json:"productName,omitempty"`
+	Vendor      *string                         `json:"vendor,omitempty"`
+	Memory      *resource.Quantity              `json:"memory,omitempty"`
+	Topology    *GPUTopologyApplyConfiguration  `json:"topology,omitempty"`
+	Partition   *GPUPartitionApplyConfiguration `json:"partition,omitempty"`
+}
+
+// GPUDeviceApplyConfiguration constructs an declarative configuration of the GPUDevice type for use with
+// apply.
+func GPUDevice() *GPUDeviceApplyConfiguration {
+	return &GPUDeviceApplyConfiguration{}
+}
+
+// WithUUID sets the UUID field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the UUID field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithUUID(value string) *GPUDeviceApplyConfiguration {
+	b.UUID = &value
+	return b
+}
+
+// WithProductName sets the ProductName field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ProductName field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithProductName(value string) *GPUDeviceApplyConfiguration {
+	b.ProductName = &value
+	return b
+}
+
+// WithVendor sets the Vendor field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Vendor field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithVendor(value string) *GPUDeviceApplyConfiguration {
+	b.Vendor = &value
+	return b
+}
+
+// WithMemory sets the Memory field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Memory field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithMemory(value resource.Quantity) *GPUDeviceApplyConfiguration {
+	b.Memory = &value
+	return b
+}
+
+// WithTopology sets the Topology field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Topology field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithTopology(value *GPUTopologyApplyConfiguration) *GPUDeviceApplyConfiguration {
+	b.Topology = value
+	return b
+}
+
+// WithPartition sets the Partition field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Partition field is set to the value of the last call.
+func (b *GPUDeviceApplyConfiguration) WithPartition(value *GPUPartitionApplyConfiguration) *GPUDeviceApplyConfiguration {
+	b.Partition = value
+	return b
+}
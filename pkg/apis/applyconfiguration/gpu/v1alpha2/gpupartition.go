@@ -0,0 +1,57 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// GPUPartitionApplyConfiguration represents an declarative configuration of the GPUPartition type for use
+// with apply.
+type GPUPartitionApplyConfiguration struct {
+	ParentUUID *string `json:"parentUUID,omitempty"`
+	Profile    *string `json:"profile,omitempty"`
+	Slices     *int    `json:"slices,omitempty"`
+}
+
+// GPUPartitionApplyConfiguration constructs an declarative configuration of the GPUPartition type for use with
+// apply.
+func GPUPartition() *GPUPartitionApplyConfiguration {
+	return &GPUPartitionApplyConfiguration{}
+}
+
+// WithParentUUID sets the ParentUUID field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ParentUUID field is set to the value of the last call.
+func (b *GPUPartitionApplyConfiguration) WithParentUUID(value string) *GPUPartitionApplyConfiguration {
+	b.ParentUUID = &value
+	return b
+}
+
+// WithProfile sets the Profile field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Profile field is set to the value of the last call.
+func (b *GPUPartitionApplyConfiguration) WithProfile(value string) *GPUPartitionApplyConfiguration {
+	b.Profile = &value
+	return b
+}
+
+// WithSlices sets the Slices field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Slices field is set to the value of the last call.
+func (b *GPUPartitionApplyConfiguration) WithSlices(value int) *GPUPartitionApplyConfiguration {
+	b.Slices = &value
+	return b
+}
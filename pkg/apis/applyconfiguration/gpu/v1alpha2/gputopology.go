@@ -0,0 +1,57 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// GPUTopologyApplyConfiguration represents an declarative configuration of the GPUTopology type for use
+// with apply.
+type GPUTopologyApplyConfiguration struct {
+	NUMANode          *int    `json:"numaNode,omitempty"`
+	PCIeRoot          *string `json:"pcieRoot,omitempty"`
+	InterconnectGroup *string `json:"interconnectGroup,omitempty"`
+}
+
+// GPUTopologyApplyConfiguration constructs an declarative configuration of the GPUTopology type for use with
+// apply.
+func GPUTopology() *GPUTopologyApplyConfiguration {
+	return &GPUTopologyApplyConfiguration{}
+}
+
+// WithNUMANode sets the NUMANode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NUMANode field is set to the value of the last call.
+func (b *GPUTopologyApplyConfiguration) WithNUMANode(value int) *GPUTopologyApplyConfiguration {
+	b.NUMANode = &value
+	return b
+}
+
+// WithPCIeRoot sets the PCIeRoot field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the PCIeRoot field is set to the value of the last call.
+func (b *GPUTopologyApplyConfiguration) WithPCIeRoot(value string) *GPUTopologyApplyConfiguration {
+	b.PCIeRoot = &value
+	return b
+}
+
+// WithInterconnectGroup sets the InterconnectGroup field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the InterconnectGroup field is set to the value of the last call.
+func (b *GPUTopologyApplyConfiguration) WithInterconnectGroup(value string) *GPUTopologyApplyConfiguration {
+	b.InterconnectGroup = &value
+	return b
+}
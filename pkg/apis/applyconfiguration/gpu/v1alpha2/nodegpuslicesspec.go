@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+)
+
+// NodeGPUSlicesSpecApplyConfiguration represents an declarative configuration of the NodeGPUSlicesSpec type for use
+// with apply.
+type NodeGPUSlicesSpecApplyConfiguration struct {
+	AllocatableGPUs []*v1alpha2.GPUDevice `json:"allocatableGPUs,omitempty"`
+}
+
+// NodeGPUSlicesSpecApplyConfiguration constructs an declarative configuration of the NodeGPUSlicesSpec type for use with
+// apply.
+func NodeGPUSlicesSpec() *NodeGPUSlicesSpecApplyConfiguration {
+	return &NodeGPUSlicesSpecApplyConfiguration{}
+}
+
+// WithAllocatableGPUs adds the given value to the AllocatableGPUs field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the AllocatableGPUs field.
+func (b *NodeGPUSlicesSpecApplyConfiguration) WithAllocatableGPUs(values ...**v1alpha2.GPUDevice) *NodeGPUSlicesSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithAllocatableGPUs")
+		}
+		b.AllocatableGPUs = append(b.AllocatableGPUs, *values[i])
+	}
+	return b
+}
@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	v1 "k8s.io/client-go/applyconfigurations/meta/v1"
+)
+
+// NodeGPUSlicesStatusApplyConfiguration represents an declarative configuration of the NodeGPUSlicesStatus type for use
+// with apply.
+type NodeGPUSlicesStatusApplyConfiguration struct {
+	Allocations      map[string][]*v1alpha2.DeviceAllocation   `json:"allocations,omitempty"`
+	NodeSuitability  map[string]v1alpha2.NodeSuitability       `json:"nodeSuitability,omitempty"`
+	DeviceHealth     map[string]DeviceHealthApplyConfiguration `json:"deviceHealth,omitempty"`
+	Conditions       []v1.ConditionApplyConfiguration          `json:"conditions,omitempty"`
+	AllocatableCount *int                                      `json:"allocatableCount,omitempty"`
+	AllocatedCount   *int                                      `json:"allocatedCount,omitempty"`
+}
+
+// NodeGPUSlicesStatusApplyConfiguration constructs an declarative configuration of the NodeGPUSlicesStatus type for use with
+// apply.
+func NodeGPUSlicesStatus() *NodeGPUSlicesStatusApplyConfiguration {
+	return &NodeGPUSlicesStatusApplyConfiguration{}
+}
+
+// WithAllocations puts the entries into the Allocations field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, the entries provided by each call will be put on the Allocations field,
+// overwriting an existing map entries in Allocations field with the same key.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithAllocations(entries map[string][]*v1alpha2.DeviceAllocation) *NodeGPUSlicesStatusApplyConfiguration {
+	if b.Allocations == nil && len(entries) > 0 {
+		b.Allocations = make(map[string][]*v1alpha2.DeviceAllocation, len(entries))
+	}
+	for k, v := range entries {
+		b.Allocations[k] = v
+	}
+	return b
+}
+
+// WithNodeSuitability puts the entries into the NodeSuitability field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, the entries provided by each call will be put on the NodeSuitability field,
+// overwriting an existing map entries in NodeSuitability field with the same key.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithNodeSuitability(entries map[string]v1alpha2.NodeSuitability) *NodeGPUSlicesStatusApplyConfiguration {
+	if b.NodeSuitability == nil && len(entries) > 0 {
+		b.NodeSuitability = make(map[string]v1alpha2.NodeSuitability, len(entries))
+	}
+	for k, v := range entries {
+		b.NodeSuitability[k] = v
+	}
+	return b
+}
+
+// WithDeviceHealth puts the entries into the DeviceHealth field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, the entries provided by each call will be put on the DeviceHealth field,
+// overwriting an existing map entries in DeviceHealth field with the same key.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithDeviceHealth(entries map[string]DeviceHealthApplyConfiguration) *NodeGPUSlicesStatusApplyConfiguration {
+	if b.DeviceHealth == nil && len(entries) > 0 {
+		b.DeviceHealth = make(map[string]DeviceHealthApplyConfiguration, len(entries))
+	}
+	for k, v := range entries {
+		b.DeviceHealth[k] = v
+	}
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithConditions(values ...*v1.ConditionApplyConfiguration) *NodeGPUSlicesStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithConditions")
+		}
+		b.Conditions = append(b.Conditions, *values[i])
+	}
+	return b
+}
+
+// WithAllocatableCount sets the AllocatableCount field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the AllocatableCount field is set to the value of the last call.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithAllocatableCount(value int) *NodeGPUSlicesStatusApplyConfiguration {
+	b.AllocatableCount = &value
+	return b
+}
+
+// WithAllocatedCount sets the AllocatedCount field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the AllocatedCount field is set to the value of the last call.
+func (b *NodeGPUSlicesStatusApplyConfiguration) WithAllocatedCount(value int) *NodeGPUSlicesStatusApplyConfiguration {
+	b.AllocatedCount = &value
+	return b
+}
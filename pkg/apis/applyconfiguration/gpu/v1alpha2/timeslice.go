@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// TimeSliceApplyConfiguration represents an declarative configuration of the TimeSlice type for use
+// with apply.
+type TimeSliceApplyConfiguration struct {
+	Index    *int `json:"index,omitempty"`
+	Replicas *int `json:"replicas,omitempty"`
+}
+
+// TimeSliceApplyConfiguration constructs an declarative configuration of the TimeSlice type for use with
+// apply.
+func TimeSlice() *TimeSliceApplyConfiguration {
+	return &TimeSliceApplyConfiguration{}
+}
+
+// WithIndex sets the Index field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Index field is set to the value of the last call.
+func (b *TimeSliceApplyConfiguration) WithIndex(value int) *TimeSliceApplyConfiguration {
+	b.Index = &value
+	return b
+}
+
+// WithReplicas sets the Replicas field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Replicas field is set to the value of the last call.
+func (b *TimeSliceApplyConfiguration) WithReplicas(value int) *TimeSliceApplyConfiguration {
+	b.Replicas = &value
+	return b
+}
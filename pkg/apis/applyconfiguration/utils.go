@@ -20,7 +20,9 @@ package applyconfiguration
 
 import (
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/applyconfiguration/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/applyconfiguration/gpu/v1alpha2"
 	v1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	v1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -28,7 +30,7 @@ import (
 // apply configuration type exists for the given GroupVersionKind.
 func ForKind(kind schema.GroupVersionKind) interface{} {
 	switch kind {
-	// Group=gpu, Version=v1alpha1
+	// Group=dra.resources.ihcsim, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithKind("DeviceAllocation"):
 		return &gpuv1alpha1.DeviceAllocationApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("DeviceSelector"):
@@ -45,6 +47,28 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &gpuv1alpha1.GPURequirementsSpecApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("NodeGPUSlices"):
 		return &gpuv1alpha1.NodeGPUSlicesApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("TimeSlicing"):
+		return &gpuv1alpha1.TimeSlicingApplyConfiguration{}
+
+		// Group=dra.resources.ihcsim, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithKind("DeviceAllocation"):
+		return &gpuv1alpha2.DeviceAllocationApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("DeviceHealth"):
+		return &gpuv1alpha2.DeviceHealthApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("GPUDevice"):
+		return &gpuv1alpha2.GPUDeviceApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("GPUPartition"):
+		return &gpuv1alpha2.GPUPartitionApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("GPUTopology"):
+		return &gpuv1alpha2.GPUTopologyApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("NodeGPUSlices"):
+		return &gpuv1alpha2.NodeGPUSlicesApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("NodeGPUSlicesSpec"):
+		return &gpuv1alpha2.NodeGPUSlicesSpecApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("NodeGPUSlicesStatus"):
+		return &gpuv1alpha2.NodeGPUSlicesStatusApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("TimeSlice"):
+		return &gpuv1alpha2.TimeSliceApplyConfiguration{}
 
 	}
 	return nil
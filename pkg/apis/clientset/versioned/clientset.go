@@ -23,6 +23,7 @@ import (
 	"net/http"
 
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha2"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -31,12 +32,14 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	GpuV1alpha1() gpuv1alpha1.GpuV1alpha1Interface
+	GpuV1alpha2() gpuv1alpha2.GpuV1alpha2Interface
 }
 
 // Clientset contains the clients for groups.
 type Clientset struct {
 	*discovery.DiscoveryClient
 	gpuV1alpha1 *gpuv1alpha1.GpuV1alpha1Client
+	gpuV1alpha2 *gpuv1alpha2.GpuV1alpha2Client
 }
 
 // GpuV1alpha1 retrieves the GpuV1alpha1Client
@@ -44,6 +47,11 @@ func (c *Clientset) GpuV1alpha1() gpuv1alpha1.GpuV1alpha1Interface {
 	return c.gpuV1alpha1
 }
 
+// GpuV1alpha2 retrieves the GpuV1alpha2Client
+func (c *Clientset) GpuV1alpha2() gpuv1alpha2.GpuV1alpha2Interface {
+	return c.gpuV1alpha2
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -92,6 +100,10 @@ func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset,
 	if err != nil {
 		return nil, err
 	}
+	cs.gpuV1alpha2, err = gpuv1alpha2.NewForConfigAndClient(&configShallowCopy, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
@@ -114,6 +126,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.gpuV1alpha1 = gpuv1alpha1.New(c)
+	cs.gpuV1alpha2 = gpuv1alpha2.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
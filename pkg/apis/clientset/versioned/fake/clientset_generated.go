@@ -22,6 +22,8 @@ import (
 	clientset "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned"
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha1"
 	fakegpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha1/fake"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha2"
+	fakegpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/typed/gpu/v1alpha2/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -83,3 +85,8 @@ var (
 func (c *Clientset) GpuV1alpha1() gpuv1alpha1.GpuV1alpha1Interface {
 	return &fakegpuv1alpha1.FakeGpuV1alpha1{Fake: &c.Fake}
 }
+
+// GpuV1alpha2 retrieves the GpuV1alpha2Client
+func (c *Clientset) GpuV1alpha2() gpuv1alpha2.GpuV1alpha2Interface {
+	return &fakegpuv1alpha2.FakeGpuV1alpha2{Fake: &c.Fake}
+}
@@ -33,7 +33,7 @@ type GpuV1alpha1Interface interface {
 	NodeGPUSlicesGetter
 }
 
-// GpuV1alpha1Client is used to interact with features provided by the gpu group.
+// GpuV1alpha1Client is used to interact with features provided by the dra.resources.ihcsim group.
 type GpuV1alpha1Client struct {
 	restClient rest.Interface
 }
@@ -22,8 +22,9 @@ import (
 )
 
 const (
-	GPURequirementsKind = "GPURequirements"
-	Version             = "v1alpha1"
+	GPUClassParametersKind = "GPUClassParameters"
+	GPURequirementsKind    = "GPURequirements"
+	Version                = "v1alpha1"
 )
 
 var SchemeGroupVersion = schema.GroupVersion{
@@ -15,5 +15,7 @@
  */
 
 // +k8s:deepcopy-gen=package
+// +groupName=dra.resources.ihcsim
+// +groupGoName=Gpu
 
 package v1alpha1
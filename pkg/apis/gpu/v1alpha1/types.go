@@ -9,12 +9,17 @@ import (
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
-// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:resource:scope=Namespaced,shortName=ngs
+// +kubebuilder:deprecatedversion:warning="dra.resources.ihcsim/v1alpha1 NodeGPUSlices is deprecated; use dra.resources.ihcsim/v1alpha2 NodeGPUSlices"
 
 // NodeGPUSlices holds the spec of GPU devices on a node, and the devices'
-// allocation state. A GPU device can be in one of three states: allocatable,
-// allocated, or prepared.
+// allocation state. A GPU device can be in one of four states: allocatable,
+// hold, allocated, or prepared.
 // The name of the object is the name of the node.
+// It's superseded by the v1alpha2 NodeGPUSlices, which separates the device
+// inventory (spec) from the allocation state (status). This version is still
+// served, so that the objects stored in it can be migrated to v1alpha2, but
+// it's no longer stored.
 type NodeGPUSlices struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -29,10 +34,15 @@ type DeviceAllocation struct {
 	Claim  corev1.TypedLocalObjectReference `json:"claim"`
 	Device *GPUDevice                       `json:"devices"`
 	State  DeviceAllocationState            `json:"state"`
+
+	// HoldTimestamp is the time when the device driver placed a temporary hold
+	// on the device. It's only set when the allocation is in the hold state.
+	HoldTimestamp *metav1.Time `json:"holdTimestamp,omitempty"`
 }
 
 // DeviceAllocationState represents the state of a GPU device. A GPU device can
-// be in one of three states: allocatable, allocated, or prepared.
+// be in one of four states: allocatable, hold, allocated, or prepared.
+// +kubebuilder:validation:Enum=allocatable;hold;allocated;prepared
 type DeviceAllocationState string
 
 const (
@@ -52,6 +62,7 @@ const (
 )
 
 // NodeSuitability describes the suitability of a node for running GPU workloads.
+// +kubebuilder:validation:Enum=suitable;unsuitable;unknown
 type NodeSuitability string
 
 const (
@@ -72,15 +83,18 @@ type NodeGPUSlicesList struct {
 
 // GPUDevice represents an allocatable GPU device on a node.
 type GPUDevice struct {
-	UUID        string `json:"uuid"`
-	ProductName string `json:"productName"`
-	Vendor      string `json:"vendor"`
+	// +kubebuilder:validation:MinLength=1
+	UUID        string            `json:"uuid"`
+	ProductName string            `json:"productName"`
+	Vendor      string            `json:"vendor"`
+	Memory      resource.Quantity `json:"memory,omitempty"`
 }
 
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
+// +kubebuilder:resource:scope=Cluster,shortName=gpucp
 
 // GPUClassParameters defines pre-start and post-complete hooks fo
 // It can be referenced by a ResourceClass object.
@@ -94,12 +108,44 @@ type GPUClassParameters struct {
 // GPUClassParametersSpec is the spec for the GPUClassParametersSpec CRD.
 type GPUClassParametersSpec struct {
 	DeviceSelector []DeviceSelector `json:"deviceSelector,omitempty"`
+
+	// DefaultAccessMode is the access mode of the claims that don't specify
+	// one. It defaults to Exclusive.
+	DefaultAccessMode AccessMode `json:"defaultAccessMode,omitempty"`
+
+	// MaxSharers is the maximum number of shared claims that a GPU can be
+	// allocated to at the same time. Setting it to 1 prevents shared claims
+	// from co-allocating GPUs.
+	// +kubebuilder:default=4
+	// +kubebuilder:validation:Minimum=1
+	MaxSharers int `json:"maxSharers,omitempty"`
+
+	// TimeSlicing splits each GPU into time-sliced replicas. If it's set, the
+	// claims of the class are allocated replicas of the GPUs instead of whole
+	// GPUs.
+	TimeSlicing *TimeSlicing `json:"timeSlicing,omitempty"`
+}
+
+// TimeSlicing describes how GPUs are time-sliced.
+type TimeSlicing struct {
+	// Replicas is the number of time-sliced replicas of each GPU.
+	// +kubebuilder:validation:Minimum=1
+	Replicas int `json:"replicas"`
 }
 
 // DeviceSelector allows one to match on a specific type of Device as part of the class.
+// The name and vendor are glob patterns (e.g. "A100-*" or "*"), with the syntax
+// defined by path.Match. An empty name or vendor matches any device.
+// +kubebuilder:validation:XValidation:rule="has(self.name) || has(self.vendor) || has(self.expression)",message="at least one of name, vendor or expression must be set"
 type DeviceSelector struct {
-	Name   string `json:"name"`
-	Vendor string `json:"vendor"`
+	Name   string `json:"name,omitempty"`
+	Vendor string `json:"vendor,omitempty"`
+
+	// Expression is an optional CEL expression that must evaluate to true for
+	// the device to be selected. The device's attributes are available through
+	// the 'device' variable, e.g. device.vendor == "nvidia" &&
+	// device.memory >= quantity("16Gi").
+	Expression string `json:"expression,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -115,7 +161,7 @@ type GPUClassParametersList struct {
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
-// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:resource:scope=Namespaced,shortName=gpureq
 
 // GPURequirements is a set of requirement parameters that is referenced by a
 // ResourceClaim object.
@@ -128,10 +174,78 @@ type GPURequirements struct {
 
 // GPURequirementsSpec is the spec for the GPURequirements CRD.
 type GPURequirementsSpec struct {
-	Count  int `json:"count,omitempty"`
-	Memory resource.Quantity
+	// Count is the number of GPUs requested.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Count int `json:"count,omitempty"`
+
+	// Memory is the minimum amount of memory each allocated GPU must have.
+	Memory resource.Quantity `json:"memory,omitempty"`
+
+	// Topology is the policy that constrains the topology of the allocated
+	// GPUs. It defaults to BestEffort.
+	Topology TopologyPolicy `json:"topology,omitempty"`
+
+	// AccessMode determines whether the allocated GPUs can be shared with other
+	// claims. It defaults to the default access mode of the class.
+	AccessMode AccessMode `json:"accessMode,omitempty"`
+
+	// Sharing determines how the allocated GPUs are shared with other claims.
+	// If it's fractional, each allocated GPU gives the claim the requested
+	// amount of memory only, and the rest of the GPU's memory remains available
+	// to other fractional claims. Fractional claims must request memory, and
+	// can't have the Shared access mode.
+	Sharing SharingPolicy `json:"sharing,omitempty"`
+
+	// PartitionProfile is the profile of the GPU partitions to allocate, e.g.
+	// "1g.10gb". If it's empty, whole GPUs are allocated.
+	PartitionProfile string `json:"partitionProfile,omitempty"`
 }
 
+// AccessMode describes whether the GPUs allocated to a claim can be shared
+// with other claims.
+// +kubebuilder:validation:Enum=Exclusive;Shared
+type AccessMode string
+
+const (
+	// the GPUs are allocated to the claim only
+	AccessModeExclusive = "Exclusive"
+
+	// the GPUs can be co-allocated to other shared claims, up to the maximum
+	// number of sharers of the class
+	AccessModeShared = "Shared"
+)
+
+// SharingPolicy describes how the GPUs allocated to a claim are shared with
+// other claims.
+// +kubebuilder:validation:Enum=fractional
+type SharingPolicy string
+
+const (
+	// the claim is allocated a fraction of the memory of each GPU, and the GPUs
+	// can be co-allocated to other fractional claims until their memory is used
+	// up
+	SharingPolicyFractional = "fractional"
+)
+
+// TopologyPolicy describes how the allocated GPUs of a claim must be placed
+// relative to each other.
+// +kubebuilder:validation:Enum=SameNUMA;SameInterconnectGroup;BestEffort
+type TopologyPolicy string
+
+const (
+	// all the GPUs must be attached to the same NUMA node
+	TopologyPolicySameNUMA = "SameNUMA"
+
+	// all the GPUs must be in the same interconnect group
+	TopologyPolicySameInterconnectGroup = "SameInterconnectGroup"
+
+	// the GPUs are placed as close to each other as possible, without
+	// rejecting the node if they can't share an interconnect group or a NUMA
+	// node
+	TopologyPolicyBestEffort = "BestEffort"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // GPURequirementsList represents the "plural" of a ResourceClaimParameters CRD object.
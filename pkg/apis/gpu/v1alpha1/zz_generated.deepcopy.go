@@ -32,7 +32,11 @@ func (in *DeviceAllocation) DeepCopyInto(out *DeviceAllocation) {
 	if in.Device != nil {
 		in, out := &in.Device, &out.Device
 		*out = new(GPUDevice)
-		**out = **in
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HoldTimestamp != nil {
+		in, out := &in.HoldTimestamp, &out.HoldTimestamp
+		*out = (*in).DeepCopy()
 	}
 	return
 }
@@ -131,6 +135,11 @@ func (in *GPUClassParametersSpec) DeepCopyInto(out *GPUClassParametersSpec) {
 		*out = make([]DeviceSelector, len(*in))
 		copy(*out, *in)
 	}
+	if in.TimeSlicing != nil {
+		in, out := &in.TimeSlicing, &out.TimeSlicing
+		*out = new(TimeSlicing)
+		**out = **in
+	}
 	return
 }
 
@@ -147,6 +156,7 @@ func (in *GPUClassParametersSpec) DeepCopy() *GPUClassParametersSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GPUDevice) DeepCopyInto(out *GPUDevice) {
 	*out = *in
+	out.Memory = in.Memory.DeepCopy()
 	return
 }
 
@@ -249,7 +259,7 @@ func (in *NodeGPUSlices) DeepCopyInto(out *NodeGPUSlices) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(GPUDevice)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -334,3 +344,19 @@ func (in *NodeGPUSlicesList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSlicing) DeepCopyInto(out *TimeSlicing) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSlicing.
+func (in *TimeSlicing) DeepCopy() *TimeSlicing {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSlicing)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"github.com/ihcsim/k8s-dra/pkg/apis"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	NodeGPUSlicesKind = "NodeGPUSlices"
+	Version           = "v1alpha2"
+)
+
+var SchemeGroupVersion = schema.GroupVersion{
+	Group:   apis.GroupName,
+	Version: Version,
+}
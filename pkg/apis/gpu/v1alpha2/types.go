@@ -0,0 +1,254 @@
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:scope=Namespaced,shortName=ngs
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.metadata.name`
+// +kubebuilder:printcolumn:name="Allocatable",type=integer,JSONPath=`.status.allocatableCount`
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.allocatedCount`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NodeGPUSlices holds the spec of GPU devices on a node, and the devices'
+// allocation state. The spec is the device inventory, written by the kubelet
+// plugin. The status is the allocation state, written through the status
+// subresource. A GPU device can be in one of four states: allocatable, hold,
+// allocated, or prepared.
+// The name of the object is the name of the node.
+type NodeGPUSlices struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeGPUSlicesSpec   `json:"spec,omitempty"`
+	Status NodeGPUSlicesStatus `json:"status,omitempty"`
+}
+
+// NodeGPUSlicesSpec is the device inventory of a node.
+type NodeGPUSlicesSpec struct {
+	AllocatableGPUs []*GPUDevice `json:"allocatableGPUs,omitempty"`
+}
+
+// NodeGPUSlicesStatus is the allocation state of the devices of a node.
+type NodeGPUSlicesStatus struct {
+	// Allocations maps the UID of a claim to the device allocations of the claim.
+	Allocations map[string][]*DeviceAllocation `json:"allocations,omitempty"`
+
+	// NodeSuitability maps the UID of a claim to the suitability of the node for
+	// the claim.
+	NodeSuitability map[string]NodeSuitability `json:"nodeSuitability,omitempty"`
+
+	// DeviceHealth maps the UUID of a device to the result of its latest health
+	// check. It's written by the kubelet plugin. Devices without an entry are
+	// considered healthy.
+	DeviceHealth map[string]DeviceHealth `json:"deviceHealth,omitempty"`
+
+	// Conditions describe the current state of the node's devices.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// AllocatableCount is the number of devices in the spec. It's written by the
+	// kubelet plugin.
+	// +kubebuilder:validation:Minimum=0
+	AllocatableCount int `json:"allocatableCount,omitempty"`
+
+	// AllocatedCount is the number of devices that are allocated or prepared.
+	// It's written by the controller, which recomputes it whenever the
+	// allocations change.
+	// +kubebuilder:validation:Minimum=0
+	AllocatedCount int `json:"allocatedCount,omitempty"`
+}
+
+// IsPrepared returns true if any of the devices allocated to the claim is
+// prepared by the kubelet plugin, in which case the claim's allocations are
+// owned by the plugin.
+func (s *NodeGPUSlicesStatus) IsPrepared(claimUID string) bool {
+	for _, allocation := range s.Allocations[claimUID] {
+		if allocation.State == DeviceAllocationStatePrepared {
+			return true
+		}
+	}
+	return false
+}
+
+// AllocatedDeviceCount returns the number of devices that are allocated or
+// prepared, across all the claims. Devices shared by several claims are counted
+// once.
+func (s *NodeGPUSlicesStatus) AllocatedDeviceCount() int {
+	allocated := map[string]bool{}
+	for _, claimAllocations := range s.Allocations {
+		for _, allocation := range claimAllocations {
+			if allocation.State == DeviceAllocationStateAllocated ||
+				allocation.State == DeviceAllocationStatePrepared {
+				allocated[allocation.Device.UUID] = true
+			}
+		}
+	}
+	return len(allocated)
+}
+
+const (
+	// NodeGPUSlicesConditionInventoryReady indicates whether the kubelet plugin
+	// has discovered the devices of the node and published them in the spec.
+	NodeGPUSlicesConditionInventoryReady = "InventoryReady"
+
+	// NodeGPUSlicesConditionHealthy indicates whether the devices of the node are
+	// healthy.
+	NodeGPUSlicesConditionHealthy = "Healthy"
+
+	// NodeGPUSlicesConditionDevicesMissing indicates whether any allocated
+	// devices have vanished from the node.
+	NodeGPUSlicesConditionDevicesMissing = "DevicesMissing"
+)
+
+// DeviceAllocation represents the allocation state of a GPU device.
+type DeviceAllocation struct {
+	Claim  corev1.TypedLocalObjectReference `json:"claim"`
+	Device *GPUDevice                       `json:"device"`
+	State  DeviceAllocationState            `json:"state"`
+
+	// Shared is true if the device can be co-allocated to other shared claims.
+	Shared bool `json:"shared,omitempty"`
+
+	// Memory is the amount of the device's memory allocated to the claim. It's
+	// only set for fractional allocations. Other allocations take up the whole
+	// device.
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// TimeSlice is the time-sliced replica of the device allocated to the
+	// claim. It's only set for time-sliced allocations.
+	TimeSlice *TimeSlice `json:"timeSlice,omitempty"`
+
+	// HoldTimestamp is the time when the device driver placed a temporary hold
+	// on the device. It's only set when the allocation is in the hold state.
+	HoldTimestamp *metav1.Time `json:"holdTimestamp,omitempty"`
+}
+
+// TimeSlice identifies a time-sliced replica of a device.
+type TimeSlice struct {
+	// Index is the index of the replica, starting from 0.
+	// +kubebuilder:validation:Minimum=0
+	Index int `json:"index"`
+
+	// Replicas is the number of time-sliced replicas of the device.
+	// +kubebuilder:validation:Minimum=1
+	Replicas int `json:"replicas"`
+}
+
+// DeviceAllocationState represents the state of a GPU device. A GPU device can
+// be in one of four states: allocatable, hold, allocated, or prepared.
+// +kubebuilder:validation:Enum=allocatable;hold;allocated;prepared
+type DeviceAllocationState string
+
+const (
+	// the kubelet plugin determines the allocatable devices on a node
+	DeviceAllocationStateAllocatable = "allocatable"
+
+	// the device driver places a temporary hold on a device if the host node
+	// is deemed suitable for satisfying a pod's resource claim
+	DeviceAllocationStateHold = "hold"
+
+	// the device driver allocates a device to a pod based on the pod's resource
+	// claim request
+	DeviceAllocationStateAllocated = "allocated"
+
+	// the kubelet plugin prepares an allocated device for use by a pod
+	DeviceAllocationStatePrepared = "prepared"
+)
+
+// DeviceHealth is the result of the latest health check of a device.
+type DeviceHealth struct {
+	Status DeviceHealthStatus `json:"status"`
+
+	// Message describes why the device is unhealthy.
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the last time the status of the device changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// DeviceHealthStatus describes the health of a device. Unhealthy devices are
+// never allocated.
+// +kubebuilder:validation:Enum=healthy;unhealthy
+type DeviceHealthStatus string
+
+const (
+	DeviceHealthStatusHealthy   = "healthy"
+	DeviceHealthStatusUnhealthy = "unhealthy"
+)
+
+// NodeSuitability describes the suitability of a node for running GPU workloads.
+// +kubebuilder:validation:Enum=suitable;unsuitable;unknown
+type NodeSuitability string
+
+const (
+	NodeSuitabilitySuitable   = "suitable"
+	NodeSuitabilityUnsuitable = "unsuitable"
+	NodeSuitabilityUnknown    = "unknown"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeGPUSlicesList represents a list of NodeGPUSlices CRD objects.
+type NodeGPUSlicesList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeGPUSlices `json:"items"`
+}
+
+// GPUDevice represents an allocatable GPU device on a node.
+type GPUDevice struct {
+	// +kubebuilder:validation:MinLength=1
+	UUID        string            `json:"uuid"`
+	ProductName string            `json:"productName"`
+	Vendor      string            `json:"vendor"`
+	Memory      resource.Quantity `json:"memory,omitempty"`
+
+	// Topology describes where the device is attached on the node. It's nil if
+	// the topology of the device is unknown.
+	Topology *GPUTopology `json:"topology,omitempty"`
+
+	// Partition is set if the device is a partition of a physical GPU. A
+	// partition and its parent GPU are never allocated at the same time.
+	Partition *GPUPartition `json:"partition,omitempty"`
+}
+
+// GPUPartition describes a partition of a physical GPU.
+type GPUPartition struct {
+	// ParentUUID is the UUID of the physical GPU that the partition belongs to.
+	// +kubebuilder:validation:MinLength=1
+	ParentUUID string `json:"parentUUID"`
+
+	// Profile is the name of the partition profile, e.g. "1g.10gb".
+	// +kubebuilder:validation:MinLength=1
+	Profile string `json:"profile"`
+
+	// Slices is the number of compute slices of the parent GPU that the
+	// partition takes up.
+	// +kubebuilder:validation:Minimum=1
+	Slices int `json:"slices"`
+}
+
+// GPUTopology describes where a GPU device is attached on its node.
+type GPUTopology struct {
+	// NUMANode is the NUMA node that the device is attached to.
+	// +kubebuilder:validation:Minimum=0
+	NUMANode *int `json:"numaNode,omitempty"`
+
+	// PCIeRoot is the PCIe root complex that the device is attached to, e.g.
+	// "pci0000:00".
+	PCIeRoot string `json:"pcieRoot,omitempty"`
+
+	// InterconnectGroup identifies the group of devices that are directly
+	// connected to each other by a high-bandwidth interconnect, e.g. NVLink.
+	InterconnectGroup string `json:"interconnectGroup,omitempty"`
+}
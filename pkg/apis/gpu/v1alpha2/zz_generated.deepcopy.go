@@ -0,0 +1,306 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceAllocation) DeepCopyInto(out *DeviceAllocation) {
+	*out = *in
+	in.Claim.DeepCopyInto(&out.Claim)
+	if in.Device != nil {
+		in, out := &in.Device, &out.Device
+		*out = new(GPUDevice)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.TimeSlice != nil {
+		in, out := &in.TimeSlice, &out.TimeSlice
+		*out = new(TimeSlice)
+		**out = **in
+	}
+	if in.HoldTimestamp != nil {
+		in, out := &in.HoldTimestamp, &out.HoldTimestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceAllocation.
+func (in *DeviceAllocation) DeepCopy() *DeviceAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceHealth) DeepCopyInto(out *DeviceHealth) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceHealth.
+func (in *DeviceHealth) DeepCopy() *DeviceHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUDevice) DeepCopyInto(out *GPUDevice) {
+	*out = *in
+	out.Memory = in.Memory.DeepCopy()
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(GPUTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(GPUPartition)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUDevice.
+func (in *GPUDevice) DeepCopy() *GPUDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUPartition) DeepCopyInto(out *GPUPartition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUPartition.
+func (in *GPUPartition) DeepCopy() *GPUPartition {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUPartition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUTopology) DeepCopyInto(out *GPUTopology) {
+	*out = *in
+	if in.NUMANode != nil {
+		in, out := &in.NUMANode, &out.NUMANode
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUTopology.
+func (in *GPUTopology) DeepCopy() *GPUTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGPUSlices) DeepCopyInto(out *NodeGPUSlices) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGPUSlices.
+func (in *NodeGPUSlices) DeepCopy() *NodeGPUSlices {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGPUSlices)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeGPUSlices) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGPUSlicesList) DeepCopyInto(out *NodeGPUSlicesList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeGPUSlices, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGPUSlicesList.
+func (in *NodeGPUSlicesList) DeepCopy() *NodeGPUSlicesList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGPUSlicesList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeGPUSlicesList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGPUSlicesSpec) DeepCopyInto(out *NodeGPUSlicesSpec) {
+	*out = *in
+	if in.AllocatableGPUs != nil {
+		in, out := &in.AllocatableGPUs, &out.AllocatableGPUs
+		*out = make([]*GPUDevice, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(GPUDevice)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGPUSlicesSpec.
+func (in *NodeGPUSlicesSpec) DeepCopy() *NodeGPUSlicesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGPUSlicesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGPUSlicesStatus) DeepCopyInto(out *NodeGPUSlicesStatus) {
+	*out = *in
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make(map[string][]*DeviceAllocation, len(*in))
+		for key, val := range *in {
+			var outVal []*DeviceAllocation
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]*DeviceAllocation, len(*in))
+				for i := range *in {
+					if (*in)[i] != nil {
+						in, out := &(*in)[i], &(*out)[i]
+						*out = new(DeviceAllocation)
+						(*in).DeepCopyInto(*out)
+					}
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NodeSuitability != nil {
+		in, out := &in.NodeSuitability, &out.NodeSuitability
+		*out = make(map[string]NodeSuitability, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DeviceHealth != nil {
+		in, out := &in.DeviceHealth, &out.DeviceHealth
+		*out = make(map[string]DeviceHealth, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGPUSlicesStatus.
+func (in *NodeGPUSlicesStatus) DeepCopy() *NodeGPUSlicesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGPUSlicesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSlice) DeepCopyInto(out *TimeSlice) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSlice.
+func (in *TimeSlice) DeepCopy() *TimeSlice {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSlice)
+	in.DeepCopyInto(out)
+	return out
+}
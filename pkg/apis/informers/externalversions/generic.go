@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	v1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -52,7 +53,7 @@ func (f *genericInformer) Lister() cache.GenericLister {
 // TODO extend this to unknown resources with a client pool
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
-	// Group=gpu, Version=v1alpha1
+	// Group=dra.resources.ihcsim, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("gpuclassparameters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Gpu().V1alpha1().GPUClassParameters().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("gpurequirements"):
@@ -60,6 +61,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case v1alpha1.SchemeGroupVersion.WithResource("nodegpuslices"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Gpu().V1alpha1().NodeGPUSlices().Informer()}, nil
 
+		// Group=dra.resources.ihcsim, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithResource("nodegpuslices"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Gpu().V1alpha2().NodeGPUSlices().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeGPUSlicesLister helps list NodeGPUSlices.
+// All objects returned here must be treated as read-only.
+type NodeGPUSlicesLister interface {
+	// List lists all NodeGPUSlices in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha2.NodeGPUSlices, err error)
+	// NodeGPUSlices returns an object that can list and get NodeGPUSlices.
+	NodeGPUSlices(namespace string) NodeGPUSlicesNamespaceLister
+	NodeGPUSlicesListerExpansion
+}
+
+// nodeGPUSlicesLister implements the NodeGPUSlicesLister interface.
+type nodeGPUSlicesLister struct {
+	indexer cache.Indexer
+}
+
+// NewNodeGPUSlicesLister returns a new NodeGPUSlicesLister.
+func NewNodeGPUSlicesLister(indexer cache.Indexer) NodeGPUSlicesLister {
+	return &nodeGPUSlicesLister{indexer: indexer}
+}
+
+// List lists all NodeGPUSlices in the indexer.
+func (s *nodeGPUSlicesLister) List(selector labels.Selector) (ret []*v1alpha2.NodeGPUSlices, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha2.NodeGPUSlices))
+	})
+	return ret, err
+}
+
+// NodeGPUSlices returns an object that can list and get NodeGPUSlices.
+func (s *nodeGPUSlicesLister) NodeGPUSlices(namespace string) NodeGPUSlicesNamespaceLister {
+	return nodeGPUSlicesNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// NodeGPUSlicesNamespaceLister helps list and get NodeGPUSlices.
+// All objects returned here must be treated as read-only.
+type NodeGPUSlicesNamespaceLister interface {
+	// List lists all NodeGPUSlices in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha2.NodeGPUSlices, err error)
+	// Get retrieves the NodeGPUSlices from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha2.NodeGPUSlices, error)
+	NodeGPUSlicesNamespaceListerExpansion
+}
+
+// nodeGPUSlicesNamespaceLister implements the NodeGPUSlicesNamespaceLister
+// interface.
+type nodeGPUSlicesNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all NodeGPUSlices in the indexer for a given namespace.
+func (s nodeGPUSlicesNamespaceLister) List(selector labels.Selector) (ret []*v1alpha2.NodeGPUSlices, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha2.NodeGPUSlices))
+	})
+	return ret, err
+}
+
+// Get retrieves the NodeGPUSlices from the indexer for a given namespace and name.
+func (s nodeGPUSlicesNamespaceLister) Get(name string) (*v1alpha2.NodeGPUSlices, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha2.Resource("nodegpuslices"), name)
+	}
+	return obj.(*v1alpha2.NodeGPUSlices), nil
+}
@@ -4,40 +4,78 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/ihcsim/k8s-dra/pkg/apis"
+	gpuapplyv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/applyconfiguration/gpu/v1alpha2"
 	draclientset "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned"
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	gpulisters "github.com/ihcsim/k8s-dra/pkg/apis/listers/gpu/v1alpha2"
 	zlog "github.com/rs/zerolog"
 	corev1 "k8s.io/api/core/v1"
 	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/retry"
 	dractrl "k8s.io/dynamic-resource-allocation/controller"
+	"k8s.io/utils/clock"
 )
 
 const (
 	apiGroup   = apis.GroupName
 	driverName = "driver.resources.ihcsim"
+
+	// fieldManager is the server-side apply field manager of the NodeGPUSlices
+	// fields owned by the controller. The controller never forces its applies,
+	// so that it doesn't take over the allocations prepared by the kubelet
+	// plugin.
+	fieldManager = "dra-ctrl"
+
+	// defaultMaxSharers is the maximum number of shared claims that a GPU can
+	// be allocated to, if the class doesn't specify it.
+	defaultMaxSharers = 4
 )
 
 var _ dractrl.Driver = &driver{}
 
 // driver implements the controller.Driver interface, to provide the actual
 // allocation and deallocation operations of GPU resources.
+// NodeGPUSlices are read from the informer cache, via nodeDevicesLister. Only
+// writes go to the API server. Hold timestamps are read from clock.
 type driver struct {
-	clientsets draclientset.Interface
-	namespace  string
-	log        zlog.Logger
+	clientsets        draclientset.Interface
+	nodeDevicesLister gpulisters.NodeGPUSlicesLister
+	expressions       *expressionEvaluator
+	clock             clock.PassiveClock
+	namespace         string
+	log               zlog.Logger
 }
 
 // NewDriver returns a new instance of the GPU driver.
-func NewDriver(clientsets draclientset.Interface, namespace string, log zlog.Logger) (*driver, error) {
+func NewDriver(
+	clientsets draclientset.Interface,
+	nodeDevicesLister gpulisters.NodeGPUSlicesLister,
+	namespace string,
+	log zlog.Logger) (*driver, error) {
+	expressions, err := newExpressionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+
 	return &driver{
-		clientsets: clientsets,
-		namespace:  namespace,
-		log:        log,
+		clientsets:        clientsets,
+		nodeDevicesLister: nodeDevicesLister,
+		expressions:       expressions,
+		clock:             clock.RealClock{},
+		namespace:         namespace,
+		log:               log,
 	}, nil
 }
 
@@ -75,6 +113,10 @@ func (d *driver) GetClassParameters(ctx context.Context, class *resourcev1alpha2
 		return nil, fmt.Errorf("error getting DeviceClassParameters called '%s': %w", class.ParametersRef.Name, err)
 	}
 
+	if err := validateClassParameters(d.expressions, &classParams.Spec); err != nil {
+		return nil, fmt.Errorf("error validating GPUClassParameters called '%s': %w", class.ParametersRef.Name, err)
+	}
+
 	d.log.Info().Msgf("found class parameters %s", classParams.GetName())
 	return &classParams.Spec, nil
 }
@@ -89,7 +131,7 @@ func (d *driver) GetClaimParameters(
 	classParameters interface{}) (interface{}, error) {
 	if claim.Spec.ParametersRef == nil {
 		d.log.Info().Msg("no claim parameters found, so using default values")
-		return gpuv1alpha1.GPURequirementsSpec{
+		return &gpuv1alpha1.GPURequirementsSpec{
 			Count: 1,
 		}, nil
 	}
@@ -111,7 +153,7 @@ func (d *driver) GetClaimParameters(
 		return nil, fmt.Errorf("error getting GPURequirements called '%v' in namespace '%v': %v", claim.Spec.ParametersRef.Name, claim.Namespace, err)
 	}
 
-	if err := d.validateClaimParameters(&claimParams.Spec); err != nil {
+	if err := validateClaimParameters(&claimParams.Spec); err != nil {
 		return nil, fmt.Errorf("error validating GPURequirements called '%v' in namespace '%v': %w", claim.Spec.ParametersRef.Name, claim.Namespace, err)
 	}
 
@@ -126,7 +168,12 @@ func (d *driver) Allocate(ctx context.Context, claimAllocations []*dractrl.Claim
 	d.log.Debug().Msg("attempting to allocate GPUs...")
 	for _, ca := range claimAllocations {
 		if selectedNode == "" {
-			ca.Error = fmt.Errorf("immediate allocation is not supported.")
+			allocatedNode, err := d.allocateImmediate(ctx, ca)
+			if err != nil {
+				ca.Error = err
+				continue
+			}
+			ca.Allocation = buildAllocationResult(allocatedNode, isShared(ca))
 			continue
 		}
 
@@ -134,7 +181,7 @@ func (d *driver) Allocate(ctx context.Context, claimAllocations []*dractrl.Claim
 			ca.Error = err
 			continue
 		}
-		ca.Allocation = buildAllocationResult(selectedNode, true)
+		ca.Allocation = buildAllocationResult(selectedNode, isShared(ca))
 	}
 }
 
@@ -152,45 +199,125 @@ func (d *driver) allocate(
 				Logger()
 	)
 
-	getOpts := metav1.GetOptions{}
-	nodeDevices, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Get(ctx, selectedNode, getOpts)
-	if err != nil {
+	log.Info().Msg("allocating GPUs...")
+	if err := d.updateNodeDevices(ctx, selectedNode, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+		allocatableGPUs, err := d.findAllocatableGPUs(nodeDevices, claimAllocation, selectedNode)
+		if err != nil {
+			return err
+		}
+
+		if nodeDevices.Status.Allocations == nil {
+			nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{}
+		}
+
+		// any holds placed on this node for the claim are replaced by the
+		// allocation
+		nodeDevices.Status.Allocations[claimUID] = d.newDeviceAllocations(claimAllocation, allocatableGPUs, gpuv1alpha2.DeviceAllocationStateAllocated)
+		return nil
+	}); err != nil {
 		return err
 	}
 
-	log.Info().Msg("allocating GPUs...")
-	allocatableGPUs, err := d.findAllocatableGPUs(nodeDevices, claimAllocation, selectedNode)
-	if err != nil {
-		return err
+	if err := d.releaseHolds(ctx, claimUID, selectedNode); err != nil {
+		log.Warn().Err(err).Msg("failed to release holds on other nodes")
 	}
 
-	for _, allocatable := range allocatableGPUs {
-		apiGroup := apis.GroupName
-		newDeviceAllocation := &gpuv1alpha1.DeviceAllocation{
+	log.Info().Msg("allocation completed")
+	return nil
+}
+
+// newDeviceAllocations returns the allocations of the GPUs to the claim, in the
+// given state. Fractional claims are allocated the requested amount of memory
+// on each GPU, and claims of time-sliced classes are allocated a free
+// time-sliced replica of each GPU.
+func (d *driver) newDeviceAllocations(
+	claimAllocation *dractrl.ClaimAllocation,
+	gpus []*availableGPU,
+	state gpuv1alpha2.DeviceAllocationState) []*gpuv1alpha2.DeviceAllocation {
+	var (
+		apiGroup    = apis.GroupName
+		claimUID    = string(claimAllocation.Claim.GetUID())
+		shared      = isShared(claimAllocation)
+		memory      = fractionalMemory(claimAllocation)
+		allocations = []*gpuv1alpha2.DeviceAllocation{}
+		now         = metav1.NewTime(d.clock.Now())
+	)
+	for _, gpu := range gpus {
+		allocation := &gpuv1alpha2.DeviceAllocation{
 			Claim: corev1.TypedLocalObjectReference{
 				APIGroup: &apiGroup,
 				Kind:     gpuv1alpha1.GPURequirementsKind,
 				Name:     claimUID,
 			},
-			Device: allocatable,
-			State:  gpuv1alpha1.DeviceAllocationStateAllocated,
+			Device:    gpu.GPUDevice,
+			State:     state,
+			Shared:    shared,
+			TimeSlice: gpu.timeSlice,
+		}
+		if memory != nil {
+			fraction := memory.DeepCopy()
+			allocation.Memory = &fraction
+		}
+		if state == gpuv1alpha2.DeviceAllocationStateHold {
+			allocation.HoldTimestamp = &now
 		}
-		nodeDevices.Allocations[claimUID] = append(nodeDevices.Allocations[claimUID], newDeviceAllocation)
+		allocations = append(allocations, allocation)
 	}
 
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Update(ctx, nodeDevices, metav1.UpdateOptions{})
-		return err
-	}); err != nil {
-		return err
+	return allocations
+}
+
+// allocateImmediate allocates GPUs to claims with immediate allocation mode,
+// where there is no node selected by the scheduler. It scans all the
+// NodeGPUSlices in the driver's namespace, and allocates the GPUs on the first
+// node that can satisfy the claim. It returns the name of the selected node.
+func (d *driver) allocateImmediate(ctx context.Context, claimAllocation *dractrl.ClaimAllocation) (string, error) {
+	claimUID := string(claimAllocation.Claim.GetUID())
+	log := d.log.With().
+		Str("podClaimName", claimAllocation.PodClaimName).
+		Str("claimUID", claimUID).
+		Logger()
+
+	nodes, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).List(labels.Everything())
+	if err != nil {
+		return "", err
 	}
 
-	log.Info().Msg("allocation completed")
-	return nil
+	// sort the nodes by name so that the node selection is deterministic
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].GetName() < nodes[j].GetName()
+	})
+
+	log.Info().Msgf("selecting node for immediate allocation from %d nodes...", len(nodes))
+	var errs error
+	for _, nodeDevices := range nodes {
+		nodeName := nodeDevices.GetName()
+		if _, err := d.findAllocatableGPUs(nodeDevices, claimAllocation, nodeName); err != nil {
+			log.Debug().Err(err).Msgf("skipping node %s", nodeName)
+			continue
+		}
+
+		if err := d.allocate(ctx, claimAllocation, nodeName); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		log.Info().Msgf("selected node %s for immediate allocation", nodeName)
+		return nodeName, nil
+	}
+
+	if errs != nil {
+		return "", fmt.Errorf("failed to allocate GPUs for claim %s: %w", claimUID, errs)
+	}
+	return "", fmt.Errorf("no nodes with sufficient GPUs found for claim %s", claimUID)
 }
 
 // Deallocate gets called when a ResourceClaim is ready to be freed.
 // see https://pkg.go.dev/k8s.io/dynamic-resource-allocation/controller#Driver
+// The NodeGPUSlices is read from the API server, since skipping a claim that's
+// missing from a stale cache would leak its GPUs. The claim's allocations are
+// removed with an explicit patch, since they may be prepared and owned by the
+// kubelet plugin.
 func (d *driver) Deallocate(ctx context.Context, claim *resourcev1alpha2.ResourceClaim) error {
 	d.log.Debug().Msg("attempting to deallocate GPUs...")
 	if !claim.Status.DeallocationRequested {
@@ -211,22 +338,20 @@ func (d *driver) Deallocate(ctx context.Context, claim *resourcev1alpha2.Resourc
 				Logger()
 	)
 
-	nodeDevices, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Get(ctx, selectedNode, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-
-	if _, exists := nodeDevices.Allocations[claimUID]; !exists {
-		log.Info().Msg("no GPUs allocated, skipping deallocation")
-		return nil
-	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodeDevices, err := d.clientsets.GpuV1alpha2().NodeGPUSlices(d.namespace).Get(ctx, selectedNode, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
 
-	log.Info().Msg("deallocating claimed GPUs...")
-	delete(nodeDevices.Allocations, claimUID)
+		claimAllocations, exists := nodeDevices.Status.Allocations[claimUID]
+		if !exists {
+			log.Info().Msg("no GPUs allocated, skipping deallocation")
+			return nil
+		}
 
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Update(ctx, nodeDevices, metav1.UpdateOptions{})
-		return err
+		log.Info().Msg("deallocating claimed GPUs...")
+		return d.removeAllocations(ctx, nodeDevices, map[string][]*gpuv1alpha2.DeviceAllocation{claimUID: claimAllocations})
 	}); err != nil {
 		return err
 	}
@@ -253,39 +378,117 @@ func (d *driver) UnsuitableNodes(ctx context.Context, pod *corev1.Pod, claims []
 	d.log.Debug().Msg("assessing potential node's suitability...")
 	var errs error
 	for _, potentialNode := range potentialNodes {
-		nodeDevices, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Get(ctx, potentialNode, metav1.GetOptions{})
-		if err != nil {
+		if _, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).Get(potentialNode); err != nil {
 			for _, claim := range claims {
 				claim.UnsuitableNodes = append(claim.UnsuitableNodes, potentialNode)
 			}
-			return nil
-		}
-
-		nodeDevicesUpdated, err := d.unsuitableNode(nodeDevices, pod, claims, potentialNode)
-		if err != nil {
-			errs = errors.Join(errs, err)
 			continue
 		}
 
-		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			_, err := d.clientsets.GpuV1alpha1().NodeGPUSlices(d.namespace).Update(ctx, nodeDevicesUpdated, metav1.UpdateOptions{})
-			return err
+		var unsuitableClaims []*dractrl.ClaimAllocation
+		if err := d.updateNodeDevices(ctx, potentialNode, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+			unsuitableClaims = d.unsuitableNode(nodeDevices, pod, claims, potentialNode)
+			return nil
 		}); err != nil {
 			errs = errors.Join(errs, err)
 			continue
 		}
+
+		for _, claim := range unsuitableClaims {
+			claim.UnsuitableNodes = append(claim.UnsuitableNodes, potentialNode)
+		}
 	}
 
 	d.log.Info().Msg("unsuitable nodes check completed")
 	return errs
 }
 
-func (d *driver) validateClaimParameters(claimParams *gpuv1alpha1.GPURequirementsSpec) error {
-	if claimParams.Count < 1 {
-		return fmt.Errorf("invalid number of GPUs requested: %v", claimParams.Count)
+// updateNodeDevices reads the NodeGPUSlices of the node from the informer cache,
+// applies the mutation to a copy of it and writes the controller-owned status
+// fields back to the API server with server-side apply. The write is skipped if the
+// mutation doesn't change the object. On conflicts, the object is re-read from
+// the API server, since the cache lags behind the writes, and the mutation is
+// re-applied.
+func (d *driver) updateNodeDevices(ctx context.Context, nodeName string, mutate func(*gpuv1alpha2.NodeGPUSlices) error) error {
+	current, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).Get(nodeName)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodeDevices := current.DeepCopy()
+		if err := mutate(nodeDevices); err != nil {
+			return err
+		}
+		nodeDevices.Status.AllocatedCount = nodeDevices.Status.AllocatedDeviceCount()
+
+		if apiequality.Semantic.DeepEqual(current, nodeDevices) {
+			d.log.Debug().Str("node", nodeName).Msg("NodeGPUSlices unchanged, skipping update")
+			return nil
+		}
+
+		applyOpts := metav1.ApplyOptions{FieldManager: fieldManager}
+		_, err := d.clientsets.GpuV1alpha2().NodeGPUSlices(d.namespace).ApplyStatus(ctx, applyConfiguration(nodeDevices), applyOpts)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+
+		latest, getErr := d.clientsets.GpuV1alpha2().NodeGPUSlices(d.namespace).Get(ctx, nodeName, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		current = latest
+		return err
+	})
+}
+
+// AllocatedCountHandler returns the NodeGPUSlices event handler that keeps
+// their allocated counts up to date. The controller is the only writer of the
+// count, so the allocations changed by the kubelet plugin, e.g. when it
+// prepares the claims allocated with structured parameters, or unprepares
+// claims, are only counted once the informer sees them.
+func (d *driver) AllocatedCountHandler(ctx context.Context) cache.ResourceEventHandler {
+	refresh := func(obj interface{}) {
+		nodeDevices, ok := obj.(*gpuv1alpha2.NodeGPUSlices)
+		if !ok || nodeDevices.Status.AllocatedCount == nodeDevices.Status.AllocatedDeviceCount() {
+			return
+		}
+
+		// the count is recomputed by every update
+		if err := d.updateNodeDevices(ctx, nodeDevices.GetName(), func(*gpuv1alpha2.NodeGPUSlices) error {
+			return nil
+		}); err != nil {
+			d.log.Error().Err(err).Str("node", nodeDevices.GetName()).Msg("failed to update allocated count")
+		}
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    refresh,
+		UpdateFunc: func(_, obj interface{}) { refresh(obj) },
+	}
+}
+
+// applyConfiguration returns the apply configuration of the NodeGPUSlices status
+// fields owned by the controller, i.e. the node suitability entries, the
+// allocated count and the allocations that aren't prepared by the kubelet
+// plugin yet. Owned fields that are left out of the configuration are removed
+// by the API server. The resource version guards against applying a stale copy
+// from the cache.
+func applyConfiguration(nodeDevices *gpuv1alpha2.NodeGPUSlices) *gpuapplyv1alpha2.NodeGPUSlicesApplyConfiguration {
+	allocations := map[string][]*gpuv1alpha2.DeviceAllocation{}
+	for claimUID, claimAllocations := range nodeDevices.Status.Allocations {
+		if nodeDevices.Status.IsPrepared(claimUID) {
+			continue
+		}
+		allocations[claimUID] = claimAllocations
+	}
+
+	return gpuapplyv1alpha2.NodeGPUSlices(nodeDevices.GetName(), nodeDevices.GetNamespace()).
+		WithResourceVersion(nodeDevices.GetResourceVersion()).
+		WithStatus(gpuapplyv1alpha2.NodeGPUSlicesStatus().
+			WithAllocations(allocations).
+			WithNodeSuitability(nodeDevices.Status.NodeSuitability).
+			WithAllocatedCount(nodeDevices.Status.AllocatedCount))
 }
 
 func buildAllocationResult(selectedNode string, shareable bool) *resourcev1alpha2.AllocationResult {
@@ -310,46 +513,222 @@ func buildAllocationResult(selectedNode string, shareable bool) *resourcev1alpha
 }
 
 func (d *driver) findAllocatableGPUs(
-	nodeDevices *gpuv1alpha1.NodeGPUSlices,
+	nodeDevices *gpuv1alpha2.NodeGPUSlices,
 	claimAllocation *dractrl.ClaimAllocation,
-	selectedNode string) ([]*gpuv1alpha1.GPUDevice, error) {
+	selectedNode string) ([]*availableGPU, error) {
 	claimParams, ok := claimAllocation.ClaimParameters.(*gpuv1alpha1.GPURequirementsSpec)
 	if !ok {
 		return nil, fmt.Errorf("unsupported claim parameters kind: %T", claimAllocation.ClaimParameters)
 	}
 
-	availableGPUs := d.availableGPUs(nodeDevices)
-	if len(availableGPUs) < claimParams.Count {
-		return nil, fmt.Errorf("insufficient GPUs on node %s for claim %s", selectedNode, claimAllocation.Claim.GetUID())
-	}
-
 	classParams, ok := claimAllocation.ClassParameters.(*gpuv1alpha1.GPUClassParametersSpec)
 	if !ok {
 		return nil, fmt.Errorf("unsupported class parameters kind: %T", claimAllocation.ClassParameters)
 	}
 
-	allocatableGPUs := []*gpuv1alpha1.GPUDevice{}
-	for _, availableGPU := range availableGPUs {
-		if len(classParams.DeviceSelector) > 0 {
-			for _, selector := range classParams.DeviceSelector {
-				if selector.Name == availableGPU.ProductName && selector.Vendor == availableGPU.Vendor {
-					allocatableGPUs = append(allocatableGPUs, availableGPU)
-				}
+	fractional := claimParams.Sharing == gpuv1alpha1.SharingPolicyFractional
+	if fractional && timeSlicingReplicas(classParams) > 0 {
+		return nil, fmt.Errorf("fractional claims can't be allocated GPUs of a time-sliced class")
+	}
+
+	var (
+		claimUID   = string(claimAllocation.Claim.GetUID())
+		candidates = []*availableGPU{}
+	)
+	for _, availableGPU := range d.availableGPUs(nodeDevices, claimUID, claimParams, classParams) {
+		if !matchPartitionProfile(availableGPU.GPUDevice, claimParams) {
+			d.log.Info().Msgf("skipping GPU %s not matching the requested partition profile %q", availableGPU.UUID, claimParams.PartitionProfile)
+			continue
+		}
+
+		if !hasSufficientMemory(availableGPU, claimParams) {
+			d.log.Info().Msgf("skipping GPU %s with insufficient free memory %s (requested %s)", availableGPU.UUID, availableGPU.freeMemory.String(), claimParams.Memory.String())
+			continue
+		}
+
+		if !d.matchDeviceSelectors(classParams.DeviceSelector, availableGPU.GPUDevice) {
+			d.log.Info().Msgf("skipping GPU %s not matched by any device selectors", availableGPU.UUID)
+			continue
+		}
+		candidates = append(candidates, availableGPU)
+	}
+
+	if len(candidates) < claimParams.Count {
+		return nil, fmt.Errorf("insufficient GPUs on node %s for claim %s", selectedNode, claimUID)
+	}
+
+	// order the GPUs by UUID. fractional claims are packed onto the GPUs with
+	// the least free memory first, so that the other GPUs are kept free for
+	// claims that need more memory.
+	sort.Slice(candidates, func(i, j int) bool {
+		if fractional {
+			if cmp := candidates[i].freeMemory.Cmp(candidates[j].freeMemory); cmp != 0 {
+				return cmp < 0
 			}
+		}
+		return candidates[i].UUID < candidates[j].UUID
+	})
+
+	// the GPUs held for the claim are preferred, so that holds are turned into
+	// allocations
+	held := map[string]bool{}
+	for _, allocation := range nodeDevices.Status.Allocations[claimUID] {
+		if allocation.State == gpuv1alpha2.DeviceAllocationStateHold {
+			held[allocation.Device.UUID] = true
+		}
+	}
+
+	selectedGPUs := selectGPUs(candidates, held, claimParams.Count, claimParams.Topology)
+	if selectedGPUs == nil {
+		return nil, fmt.Errorf("insufficient GPUs satisfying the %s topology policy on node %s for claim %s", claimParams.Topology, selectedNode, claimUID)
+	}
+
+	return selectedGPUs, nil
+}
+
+// matchDeviceSelectors returns true if the GPU matches at least one of the
+// device selectors. The selectors' name and vendor are glob patterns, with the
+// syntax defined by path.Match. If a selector has an expression, it must also
+// evaluate to true. If there are no selectors, all GPUs match.
+func (d *driver) matchDeviceSelectors(selectors []gpuv1alpha1.DeviceSelector, gpu *gpuv1alpha2.GPUDevice) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+
+	for _, selector := range selectors {
+		if !matchPattern(selector.Name, gpu.ProductName) || !matchPattern(selector.Vendor, gpu.Vendor) {
+			continue
+		}
+
+		if selector.Expression == "" {
+			return true
+		}
+
+		matched, err := d.expressions.eval(selector.Expression, gpu)
+		if err != nil {
+			d.log.Error().Err(err).Msgf("failed to evaluate device selector expression for GPU %s", gpu.UUID)
 			continue
 		}
-		allocatableGPUs = append(allocatableGPUs, availableGPU)
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPattern reports whether value matches the glob pattern. An empty pattern
+// matches any value, and malformed patterns match nothing.
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// sharing returns whether the claim shares its GPUs with other shared claims,
+// and the maximum number of shared claims that each GPU can be allocated to.
+// Claims that don't specify an access mode use the default access mode of their
+// class. Fractional claims and the claims of time-sliced classes never share
+// their GPUs with shared claims.
+func sharing(claimParams *gpuv1alpha1.GPURequirementsSpec, classParams *gpuv1alpha1.GPUClassParametersSpec) (bool, int) {
+	if claimParams.Sharing == gpuv1alpha1.SharingPolicyFractional || timeSlicingReplicas(classParams) > 0 {
+		return false, 0
+	}
+
+	accessMode := claimParams.AccessMode
+	if accessMode == "" {
+		accessMode = classParams.DefaultAccessMode
+	}
+
+	maxSharers := classParams.MaxSharers
+	if maxSharers == 0 {
+		maxSharers = defaultMaxSharers
+	}
+
+	return accessMode == gpuv1alpha1.AccessModeShared, maxSharers
+}
+
+// isShared returns true if the claim shares its GPUs with other shared claims.
+// Claims with unsupported parameters are treated as exclusive.
+func isShared(claimAllocation *dractrl.ClaimAllocation) bool {
+	claimParams, ok := claimAllocation.ClaimParameters.(*gpuv1alpha1.GPURequirementsSpec)
+	if !ok {
+		return false
+	}
+
+	classParams, ok := claimAllocation.ClassParameters.(*gpuv1alpha1.GPUClassParametersSpec)
+	if !ok {
+		return false
+	}
+
+	shared, _ := sharing(claimParams, classParams)
+	return shared
+}
+
+// timeSlicingReplicas returns the number of time-sliced replicas of each GPU of
+// the class, or 0 if the class isn't time-sliced. A single replica is the same
+// as no time-slicing.
+func timeSlicingReplicas(classParams *gpuv1alpha1.GPUClassParametersSpec) int {
+	if classParams.TimeSlicing == nil || classParams.TimeSlicing.Replicas <= 1 {
+		return 0
+	}
+	return classParams.TimeSlicing.Replicas
+}
+
+// fractionalMemory returns the amount of memory that the claim is allocated on
+// each of its GPUs, or nil if the claim isn't fractional.
+func fractionalMemory(claimAllocation *dractrl.ClaimAllocation) *resource.Quantity {
+	claimParams, ok := claimAllocation.ClaimParameters.(*gpuv1alpha1.GPURequirementsSpec)
+	if !ok || claimParams.Sharing != gpuv1alpha1.SharingPolicyFractional {
+		return nil
+	}
+
+	return &claimParams.Memory
+}
+
+// matchPartitionProfile returns true if the GPU is a partition with the profile
+// requested by the claim. If the claim doesn't request a profile, only whole
+// GPUs match.
+func matchPartitionProfile(gpu *gpuv1alpha2.GPUDevice, claimParams *gpuv1alpha1.GPURequirementsSpec) bool {
+	if claimParams.PartitionProfile == "" {
+		return gpu.Partition == nil
+	}
+
+	return gpu.Partition != nil && gpu.Partition.Profile == claimParams.PartitionProfile
+}
+
+// hasSufficientMemory returns true if the GPU has at least the amount of memory
+// requested by the claim free. Claims that don't specify memory are satisfied
+// by any GPU.
+func hasSufficientMemory(gpu *availableGPU, claimParams *gpuv1alpha1.GPURequirementsSpec) bool {
+	if claimParams.Memory.IsZero() {
+		return true
 	}
 
-	return allocatableGPUs, nil
+	return gpu.freeMemory.Cmp(claimParams.Memory) >= 0
 }
 
+// unsuitableNode assesses the suitability of the node for each claim, and
+// records the result in nodeDevices. Temporary holds are placed on the GPUs of
+// the node, for the claims that it is suitable for. It returns the claims that
+// the node is unsuitable for.
 func (d *driver) unsuitableNode(
-	nodeDevices *gpuv1alpha1.NodeGPUSlices,
+	nodeDevices *gpuv1alpha2.NodeGPUSlices,
 	pod *corev1.Pod,
 	claims []*dractrl.ClaimAllocation,
-	potentialNode string) (*gpuv1alpha1.NodeGPUSlices, error) {
-	nodeDeviceClone := nodeDevices.DeepCopy()
+	potentialNode string) []*dractrl.ClaimAllocation {
+	if nodeDevices.Status.Allocations == nil {
+		nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{}
+	}
+	if nodeDevices.Status.NodeSuitability == nil {
+		nodeDevices.Status.NodeSuitability = map[string]gpuv1alpha2.NodeSuitability{}
+	}
+
+	var unsuitableClaims []*dractrl.ClaimAllocation
 	for _, claim := range claims {
 		claimParams, ok := claim.ClaimParameters.(*gpuv1alpha1.GPURequirementsSpec)
 		if !ok {
@@ -357,55 +736,219 @@ func (d *driver) unsuitableNode(
 			continue
 		}
 
-		// if the number of allocated GPUs is less than the requested count, mark the node as
-		// unsuitable
+		// if the claim already has GPUs allocated on this node, the node is
+		// suitable only if the allocation satisfies the requested count
 		claimUID := string(claim.Claim.GetUID())
-		allocatedCount := d.allocatedCount(nodeDevices, claimUID)
-		if claimParams.Count != allocatedCount {
-			d.log.Info().Msgf("insufficient GPUs on node %s for claim %s, marking node as unsuitable", potentialNode, claimUID)
-			claim.UnsuitableNodes = append(claim.UnsuitableNodes, potentialNode)
-			nodeDeviceClone.NodeSuitability[claimUID] = gpuv1alpha1.NodeSuitabilityUnsuitable
+		if allocatedCount := d.allocatedCount(nodeDevices, claimUID); allocatedCount > 0 {
+			if allocatedCount < claimParams.Count {
+				d.log.Info().Msgf("insufficient GPUs allocated on node %s for claim %s, marking node as unsuitable", potentialNode, claimUID)
+				unsuitableClaims = append(unsuitableClaims, claim)
+				nodeDevices.Status.NodeSuitability[claimUID] = gpuv1alpha2.NodeSuitabilityUnsuitable
+				continue
+			}
+			nodeDevices.Status.NodeSuitability[claimUID] = gpuv1alpha2.NodeSuitabilitySuitable
 			continue
 		}
-		nodeDeviceClone.NodeSuitability[claimUID] = gpuv1alpha1.NodeSuitabilitySuitable
+
+		// otherwise, mark the node as unsuitable if it doesn't have enough GPUs
+		// that satisfy the claim and class parameters. the devices held by the
+		// preceding claims of the pod aren't available to this claim.
+		allocatableGPUs, err := d.findAllocatableGPUs(nodeDevices, claim, potentialNode)
+		if err != nil {
+			d.log.Info().Err(err).Msgf("no allocatable GPUs on node %s for claim %s, marking node as unsuitable", potentialNode, claimUID)
+			unsuitableClaims = append(unsuitableClaims, claim)
+			nodeDevices.Status.NodeSuitability[claimUID] = gpuv1alpha2.NodeSuitabilityUnsuitable
+			delete(nodeDevices.Status.Allocations, claimUID)
+			continue
+		}
+		nodeDevices.Status.NodeSuitability[claimUID] = gpuv1alpha2.NodeSuitabilitySuitable
+
+		// place temporary holds on the GPUs, so that they aren't offered to
+		// other pods until the claim is allocated or the holds expire. existing
+		// holds on the same GPUs are kept, but their TTL is restarted since the
+		// claim is still being scheduled.
+		if sameGPUs(nodeDevices.Status.Allocations[claimUID], allocatableGPUs) {
+			now := metav1.NewTime(d.clock.Now())
+			for _, allocation := range nodeDevices.Status.Allocations[claimUID] {
+				allocation.HoldTimestamp = &now
+			}
+			continue
+		}
+		d.log.Info().Msgf("placing holds on %d GPUs on node %s for claim %s", len(allocatableGPUs), potentialNode, claimUID)
+		nodeDevices.Status.Allocations[claimUID] = d.newDeviceAllocations(claim, allocatableGPUs, gpuv1alpha2.DeviceAllocationStateHold)
 	}
 
-	return nodeDeviceClone, nil
+	return unsuitableClaims
 }
 
-func (d *driver) allocatedCount(nodeDevices *gpuv1alpha1.NodeGPUSlices, claimUID string) int {
-	// if existing allocated GPUs are found for this claim, return the count
-	if allocations, exists := nodeDevices.Allocations[claimUID]; exists {
-		allocatedCount := 0
-		for _, allocation := range allocations {
-			if allocation.State == gpuv1alpha1.DeviceAllocationStateAllocated {
-				allocatedCount++
-			}
+// sameGPUs returns true if the allocations are on exactly the given GPUs.
+func sameGPUs(allocations []*gpuv1alpha2.DeviceAllocation, gpus []*availableGPU) bool {
+	if len(allocations) != len(gpus) {
+		return false
+	}
+
+	uuids := map[string]bool{}
+	for _, allocation := range allocations {
+		uuids[allocation.Device.UUID] = true
+	}
+
+	for _, gpu := range gpus {
+		if !uuids[gpu.UUID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// allocatedCount returns the number of GPUs allocated to the claim, including
+// the GPUs that have been prepared. GPUs held for the claim aren't counted.
+func (d *driver) allocatedCount(nodeDevices *gpuv1alpha2.NodeGPUSlices, claimUID string) int {
+	allocatedCount := 0
+	for _, allocation := range nodeDevices.Status.Allocations[claimUID] {
+		if allocation.State == gpuv1alpha2.DeviceAllocationStateAllocated ||
+			allocation.State == gpuv1alpha2.DeviceAllocationStatePrepared {
+			allocatedCount++
 		}
-		return allocatedCount
 	}
+	return allocatedCount
+}
 
-	return len(d.availableGPUs(nodeDevices))
+// availableGPU is a GPU that can be allocated to a claim, along with its memory
+// that isn't allocated to fractional claims yet. For the claims of time-sliced
+// classes, it also has the free time-sliced replica to allocate.
+type availableGPU struct {
+	*gpuv1alpha2.GPUDevice
+	freeMemory resource.Quantity
+	timeSlice  *gpuv1alpha2.TimeSlice
 }
 
+// availableGPUs returns the GPUs on the node that can be allocated to the claim
+// with the given UID, along with their free memory. GPUs that are allocated,
+// prepared or held by exclusive claims are unavailable. GPUs that are only
+// allocated, prepared or held by shared claims are available to shared claims,
+// as long as they have fewer than the maximum number of sharers. GPUs that are
+// only allocated, prepared or held by fractional claims are available to
+// fractional claims. GPUs whose time-sliced replicas are only allocated,
+// prepared or held by claims of time-sliced classes are available to the claims
+// of time-sliced classes with the same number of replicas, as long as they have
+// free replicas. Partitions of GPUs that are in use, and GPUs with partitions
+// that are in use, are unavailable. Unhealthy GPUs are never available. GPUs
+// held by the claim itself are considered available to it.
 func (d *driver) availableGPUs(
-	nodeDevices *gpuv1alpha1.NodeGPUSlices) map[string]*gpuv1alpha1.GPUDevice {
-	available := map[string]*gpuv1alpha1.GPUDevice{}
-	for _, gpu := range nodeDevices.AllocatableGPUs {
+	nodeDevices *gpuv1alpha2.NodeGPUSlices,
+	claimUID string,
+	claimParams *gpuv1alpha1.GPURequirementsSpec,
+	classParams *gpuv1alpha1.GPUClassParametersSpec) map[string]*availableGPU {
+	var (
+		shared, maxSharers = sharing(claimParams, classParams)
+		fractional         = claimParams.Sharing == gpuv1alpha1.SharingPolicyFractional
+		replicas           = timeSlicingReplicas(classParams)
+		available          = map[string]*availableGPU{}
+	)
+	for _, gpu := range nodeDevices.Spec.AllocatableGPUs {
+		if health, found := nodeDevices.Status.DeviceHealth[gpu.UUID]; found && health.Status == gpuv1alpha2.DeviceHealthStatusUnhealthy {
+			d.log.Info().Msgf("skipping unhealthy GPU %s: %s", gpu.UUID, health.Message)
+			continue
+		}
+
 		d.log.Info().Msgf("found allocatable GPU %s", gpu.UUID)
-		available[gpu.UUID] = gpu
+		available[gpu.UUID] = &availableGPU{
+			GPUDevice:  gpu,
+			freeMemory: gpu.Memory.DeepCopy(),
+		}
 	}
 
-	// find the GPUs that are already allocated, regardless of their claims
-	// and remove them from the available list
-	for _, allocations := range nodeDevices.Allocations {
+	// find the GPUs that are already allocated or held, regardless of their
+	// claims, and account for their sharers, free memory and used time-sliced
+	// replicas
+	var (
+		exclusive     = map[string]bool{}
+		sharers       = map[string]int{}
+		fractions     = map[string]int{}
+		timeSlices    = map[string]map[int]bool{}
+		sliceReplicas = map[string]int{}
+		inUse         = map[string]bool{}
+		parentsInUse  = map[string]bool{}
+	)
+	for uid, allocations := range nodeDevices.Status.Allocations {
 		for _, allocation := range allocations {
-			if allocation.State == gpuv1alpha1.DeviceAllocationStateAllocated {
-				d.log.Info().Msgf("remove allocated GPU %s from available list", allocation.Device.UUID)
-				delete(available, allocation.Device.UUID)
+			if uid == claimUID && allocation.State == gpuv1alpha2.DeviceAllocationStateHold {
+				continue
+			}
+
+			uuid := allocation.Device.UUID
+			inUse[uuid] = true
+			if partition := allocation.Device.Partition; partition != nil {
+				parentsInUse[partition.ParentUUID] = true
 			}
+
+			switch {
+			case allocation.Memory != nil:
+				fractions[uuid]++
+				if gpu, exists := available[uuid]; exists {
+					gpu.freeMemory.Sub(*allocation.Memory)
+				}
+			case allocation.TimeSlice != nil:
+				if timeSlices[uuid] == nil {
+					timeSlices[uuid] = map[int]bool{}
+				}
+				timeSlices[uuid][allocation.TimeSlice.Index] = true
+
+				// GPUs sliced into different numbers of replicas can't be
+				// allocated to any more claims
+				if r, exists := sliceReplicas[uuid]; exists && r != allocation.TimeSlice.Replicas {
+					sliceReplicas[uuid] = -1
+				} else if !exists {
+					sliceReplicas[uuid] = allocation.TimeSlice.Replicas
+				}
+			case allocation.Shared:
+				sharers[uuid]++
+			default:
+				exclusive[uuid] = true
+			}
+		}
+	}
+
+	// remove the GPUs that can't be allocated to the claim from the available
+	// list. a partition and its parent GPU are never allocated at the same
+	// time.
+	for uuid, gpu := range available {
+		switch {
+		case gpu.Partition != nil && inUse[gpu.Partition.ParentUUID]:
+			d.log.Info().Msgf("parent GPU %s of partition %s is in use", gpu.Partition.ParentUUID, uuid)
+		case parentsInUse[uuid]:
+			d.log.Info().Msgf("partitions of GPU %s are in use", uuid)
+		case exclusive[uuid]:
+		case sharers[uuid] == 0 && fractions[uuid] == 0 && len(timeSlices[uuid]) == 0:
+			if replicas > 0 {
+				gpu.timeSlice = &gpuv1alpha2.TimeSlice{Index: 0, Replicas: replicas}
+			}
+			continue
+		case fractional && sharers[uuid] == 0 && len(timeSlices[uuid]) == 0:
+			d.log.Info().Msgf("GPU %s has %s free memory", uuid, gpu.freeMemory.String())
+			continue
+		case shared && fractions[uuid] == 0 && len(timeSlices[uuid]) == 0 && sharers[uuid] < maxSharers:
+			d.log.Info().Msgf("GPU %s is shared by %d claims", uuid, sharers[uuid])
+			continue
+		case replicas > 0 && sharers[uuid] == 0 && fractions[uuid] == 0 && sliceReplicas[uuid] == replicas && len(timeSlices[uuid]) < replicas:
+			gpu.timeSlice = &gpuv1alpha2.TimeSlice{Index: freeTimeSlice(timeSlices[uuid]), Replicas: replicas}
+			d.log.Info().Msgf("GPU %s has %d of %d time-sliced replicas in use", uuid, len(timeSlices[uuid]), replicas)
+			continue
 		}
+
+		d.log.Info().Msgf("remove allocated GPU %s from available list", uuid)
+		delete(available, uuid)
 	}
 
 	return available
 }
+
+// freeTimeSlice returns the lowest index that isn't in use.
+func freeTimeSlice(used map[int]bool) int {
+	index := 0
+	for used[index] {
+		index++
+	}
+	return index
+}
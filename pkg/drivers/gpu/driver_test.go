@@ -0,0 +1,456 @@
+package gpu
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihcsim/k8s-dra/pkg/apis"
+	drafake "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned/fake"
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	gpulisters "github.com/ihcsim/k8s-dra/pkg/apis/listers/gpu/v1alpha2"
+	zlog "github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	dractrl "k8s.io/dynamic-resource-allocation/controller"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+const (
+	// testClaimUID is the UID of the claim being allocated, which holds GPU-7.
+	testClaimUID = "claim"
+
+	testNamespace = "dra-system"
+)
+
+// testNow is the time of the fake clock of the test drivers. API timestamps
+// have a precision of seconds.
+var testNow = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestDriver returns a driver backed by a fake clientset with the given
+// NodeGPUSlices, and a fake clock. The NodeGPUSlices are also added to the
+// returned informer cache, which the driver reads them from.
+func newTestDriver(t *testing.T, nodes ...*gpuv1alpha2.NodeGPUSlices) (*driver, *drafake.Clientset, cache.Indexer, *testingclock.FakeClock) {
+	t.Helper()
+
+	var (
+		clientsets = drafake.NewSimpleClientset()
+		indexer    = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		fakeClock  = testingclock.NewFakeClock(testNow)
+	)
+	for _, nodeDevices := range nodes {
+		nodeDevices.SetNamespace(testNamespace)
+		created, err := clientsets.GpuV1alpha2().NodeGPUSlices(testNamespace).Create(context.Background(), nodeDevices, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("failed to create NodeGPUSlices %s: %v", nodeDevices.GetName(), err)
+		}
+		if err := indexer.Add(created); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clientsets.ClearActions()
+
+	// the fake clientset merges applies like strategic merge patches, which
+	// fail on the allocations map. applies are recorded without being stored,
+	// and checked with appliedStatuses.
+	clientsets.PrependReactor("patch", "nodegpuslices", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj, err := clientsets.Tracker().Get(action.GetResource(), action.GetNamespace(), patch.GetName())
+		return true, obj, err
+	})
+
+	return &driver{
+		clientsets:        clientsets,
+		nodeDevicesLister: gpulisters.NewNodeGPUSlicesLister(indexer),
+		clock:             fakeClock,
+		namespace:         testNamespace,
+		log:               zlog.Nop(),
+	}, clientsets, indexer, fakeClock
+}
+
+// appliedStatuses returns the NodeGPUSlices status configurations applied by
+// the driver, keyed by node name.
+func appliedStatuses(t *testing.T, clientsets *drafake.Clientset) map[string]*gpuv1alpha2.NodeGPUSlices {
+	t.Helper()
+
+	applied := map[string]*gpuv1alpha2.NodeGPUSlices{}
+	for _, action := range clientsets.Actions() {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType || patch.GetSubresource() != "status" {
+			continue
+		}
+
+		nodeDevices := &gpuv1alpha2.NodeGPUSlices{}
+		if err := json.Unmarshal(patch.GetPatch(), nodeDevices); err != nil {
+			t.Fatalf("failed to decode apply configuration: %v", err)
+		}
+		applied[patch.GetName()] = nodeDevices
+	}
+	return applied
+}
+
+func gpuDevice(uuid, memory string) *gpuv1alpha2.GPUDevice {
+	return &gpuv1alpha2.GPUDevice{
+		UUID:        uuid,
+		ProductName: "A100-SXM4-40GB",
+		Vendor:      "nvidia",
+		Memory:      resource.MustParse(memory),
+	}
+}
+
+func partitionDevice(uuid, parentUUID string) *gpuv1alpha2.GPUDevice {
+	gpu := gpuDevice(uuid, "10Gi")
+	gpu.Partition = &gpuv1alpha2.GPUPartition{ParentUUID: parentUUID, Profile: "1g.10gb", Slices: 1}
+	return gpu
+}
+
+func deviceAllocation(claimUID string, gpu *gpuv1alpha2.GPUDevice, state gpuv1alpha2.DeviceAllocationState) *gpuv1alpha2.DeviceAllocation {
+	apiGroup := apis.GroupName
+	return &gpuv1alpha2.DeviceAllocation{
+		Claim: corev1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     gpuv1alpha1.GPURequirementsKind,
+			Name:     claimUID,
+		},
+		Device: gpu,
+		State:  state,
+	}
+}
+
+func sharedAllocation(claimUID string, gpu *gpuv1alpha2.GPUDevice) *gpuv1alpha2.DeviceAllocation {
+	allocation := deviceAllocation(claimUID, gpu, gpuv1alpha2.DeviceAllocationStateAllocated)
+	allocation.Shared = true
+	return allocation
+}
+
+func fractionalAllocation(claimUID string, gpu *gpuv1alpha2.GPUDevice, memory string) *gpuv1alpha2.DeviceAllocation {
+	allocation := deviceAllocation(claimUID, gpu, gpuv1alpha2.DeviceAllocationStateAllocated)
+	quantity := resource.MustParse(memory)
+	allocation.Memory = &quantity
+	return allocation
+}
+
+func timeSliceAllocation(claimUID string, gpu *gpuv1alpha2.GPUDevice, index, replicas int) *gpuv1alpha2.DeviceAllocation {
+	allocation := deviceAllocation(claimUID, gpu, gpuv1alpha2.DeviceAllocationStatePrepared)
+	allocation.TimeSlice = &gpuv1alpha2.TimeSlice{Index: index, Replicas: replicas}
+	return allocation
+}
+
+// testNodeDevices returns the NodeGPUSlices of a node with 40Gi GPUs in use by
+// all kinds of claims:
+//
+//	GPU-0    allocated to an exclusive claim
+//	GPU-1    shared by 2 claims
+//	GPU-2    30Gi allocated to a fractional claim
+//	GPU-3    time-sliced replicas 0 and 2 of 4 in use
+//	GPU-4    partitioned, with partition GPU-4-P0 allocated and GPU-4-P1 free
+//	GPU-5    free
+//	GPU-6    unhealthy
+//	GPU-7    held by the claim being allocated
+//	GPU-8    held by another claim
+func testNodeDevices() *gpuv1alpha2.NodeGPUSlices {
+	gpus := map[string]*gpuv1alpha2.GPUDevice{}
+	for _, uuid := range []string{"GPU-0", "GPU-1", "GPU-2", "GPU-3", "GPU-4", "GPU-5", "GPU-6", "GPU-7", "GPU-8"} {
+		gpus[uuid] = gpuDevice(uuid, "40Gi")
+	}
+	gpus["GPU-4-P0"] = partitionDevice("GPU-4-P0", "GPU-4")
+	gpus["GPU-4-P1"] = partitionDevice("GPU-4-P1", "GPU-4")
+
+	nodeDevices := &gpuv1alpha2.NodeGPUSlices{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+		Status: gpuv1alpha2.NodeGPUSlicesStatus{
+			Allocations: map[string][]*gpuv1alpha2.DeviceAllocation{
+				"exclusive":    {deviceAllocation("exclusive", gpus["GPU-0"], gpuv1alpha2.DeviceAllocationStatePrepared)},
+				"shared-a":     {sharedAllocation("shared-a", gpus["GPU-1"])},
+				"shared-b":     {sharedAllocation("shared-b", gpus["GPU-1"])},
+				"fractional-a": {fractionalAllocation("fractional-a", gpus["GPU-2"], "30Gi")},
+				"sliced-a":     {timeSliceAllocation("sliced-a", gpus["GPU-3"], 0, 4)},
+				"sliced-b":     {timeSliceAllocation("sliced-b", gpus["GPU-3"], 2, 4)},
+				"partition":    {deviceAllocation("partition", gpus["GPU-4-P0"], gpuv1alpha2.DeviceAllocationStateAllocated)},
+				testClaimUID:   {deviceAllocation(testClaimUID, gpus["GPU-7"], gpuv1alpha2.DeviceAllocationStateHold)},
+				"other":        {deviceAllocation("other", gpus["GPU-8"], gpuv1alpha2.DeviceAllocationStateHold)},
+			},
+			DeviceHealth: map[string]gpuv1alpha2.DeviceHealth{
+				"GPU-5": {Status: gpuv1alpha2.DeviceHealthStatusHealthy},
+				"GPU-6": {Status: gpuv1alpha2.DeviceHealthStatusUnhealthy, Message: "XID 79"},
+			},
+		},
+	}
+	for _, uuid := range []string{"GPU-0", "GPU-1", "GPU-2", "GPU-3", "GPU-4", "GPU-4-P0", "GPU-4-P1", "GPU-5", "GPU-6", "GPU-7", "GPU-8"} {
+		nodeDevices.Spec.AllocatableGPUs = append(nodeDevices.Spec.AllocatableGPUs, gpus[uuid])
+	}
+	return nodeDevices
+}
+
+func testClaimAllocation(claimUID string, claimParams, classParams interface{}) *dractrl.ClaimAllocation {
+	return &dractrl.ClaimAllocation{
+		Claim: &resourcev1alpha2.ResourceClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimUID, UID: types.UID(claimUID)},
+		},
+		ClaimParameters: claimParams,
+		ClassParameters: classParams,
+	}
+}
+
+func TestAvailableGPUs(t *testing.T) {
+	// free whole GPUs and partitions, with their free memory
+	free := map[string]string{"GPU-4-P1": "10Gi", "GPU-5": "40Gi", "GPU-7": "40Gi"}
+	with := func(extra map[string]string) map[string]string {
+		merged := map[string]string{}
+		for _, m := range []map[string]string{free, extra} {
+			for uuid, memory := range m {
+				merged[uuid] = memory
+			}
+		}
+		return merged
+	}
+
+	testCases := []struct {
+		name        string
+		claimUID    string
+		claimParams gpuv1alpha1.GPURequirementsSpec
+		classParams gpuv1alpha1.GPUClassParametersSpec
+
+		// the free memory of the available GPUs, and the indices of their
+		// time-sliced replicas to allocate
+		want           map[string]string
+		wantTimeSlices map[string]int
+	}{
+		{
+			name:     "exclusive",
+			claimUID: testClaimUID,
+			want:     free,
+		},
+		{
+			name:     "exclusive without holds",
+			claimUID: "new",
+			want:     map[string]string{"GPU-4-P1": "10Gi", "GPU-5": "40Gi"},
+		},
+		{
+			name:        "shared",
+			claimUID:    testClaimUID,
+			claimParams: gpuv1alpha1.GPURequirementsSpec{AccessMode: gpuv1alpha1.AccessModeShared},
+			want:        with(map[string]string{"GPU-1": "40Gi"}),
+		},
+		{
+			name:        "shared by default access mode of class",
+			claimUID:    testClaimUID,
+			classParams: gpuv1alpha1.GPUClassParametersSpec{DefaultAccessMode: gpuv1alpha1.AccessModeShared},
+			want:        with(map[string]string{"GPU-1": "40Gi"}),
+		},
+		{
+			name:        "exclusive overriding default access mode of class",
+			claimUID:    testClaimUID,
+			claimParams: gpuv1alpha1.GPURequirementsSpec{AccessMode: gpuv1alpha1.AccessModeExclusive},
+			classParams: gpuv1alpha1.GPUClassParametersSpec{DefaultAccessMode: gpuv1alpha1.AccessModeShared},
+			want:        free,
+		},
+		{
+			name:        "shared with max sharers",
+			claimUID:    testClaimUID,
+			claimParams: gpuv1alpha1.GPURequirementsSpec{AccessMode: gpuv1alpha1.AccessModeShared},
+			classParams: gpuv1alpha1.GPUClassParametersSpec{MaxSharers: 2},
+			want:        free,
+		},
+		{
+			name:        "fractional",
+			claimUID:    testClaimUID,
+			claimParams: gpuv1alpha1.GPURequirementsSpec{Sharing: gpuv1alpha1.SharingPolicyFractional, Memory: resource.MustParse("8Gi")},
+			want:        with(map[string]string{"GPU-2": "10Gi"}),
+		},
+		{
+			name:        "time-sliced",
+			claimUID:    testClaimUID,
+			classParams: gpuv1alpha1.GPUClassParametersSpec{TimeSlicing: &gpuv1alpha1.TimeSlicing{Replicas: 4}},
+			want:        with(map[string]string{"GPU-3": "40Gi"}),
+			wantTimeSlices: map[string]int{
+				"GPU-3":    1,
+				"GPU-4-P1": 0,
+				"GPU-5":    0,
+				"GPU-7":    0,
+			},
+		},
+		{
+			name:        "time-sliced into other number of replicas",
+			claimUID:    testClaimUID,
+			classParams: gpuv1alpha1.GPUClassParametersSpec{TimeSlicing: &gpuv1alpha1.TimeSlicing{Replicas: 2}},
+			want:        free,
+			wantTimeSlices: map[string]int{
+				"GPU-4-P1": 0,
+				"GPU-5":    0,
+				"GPU-7":    0,
+			},
+		},
+		{
+			name:        "single time-sliced replica",
+			claimUID:    testClaimUID,
+			classParams: gpuv1alpha1.GPUClassParametersSpec{TimeSlicing: &gpuv1alpha1.TimeSlicing{Replicas: 1}},
+			want:        free,
+		},
+	}
+
+	d := &driver{log: zlog.Nop()}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			available := d.availableGPUs(testNodeDevices(), tc.claimUID, &tc.claimParams, &tc.classParams)
+			if len(available) != len(tc.want) {
+				t.Errorf("expected %d available GPUs, got %d", len(tc.want), len(available))
+			}
+
+			for uuid, gpu := range available {
+				memory, expected := tc.want[uuid]
+				if !expected {
+					t.Errorf("unexpected available GPU %s", uuid)
+					continue
+				}
+				if gpu.freeMemory.Cmp(resource.MustParse(memory)) != 0 {
+					t.Errorf("expected GPU %s to have %s free memory, got %s", uuid, memory, gpu.freeMemory.String())
+				}
+
+				index, timeSliced := tc.wantTimeSlices[uuid]
+				switch {
+				case !timeSliced && gpu.timeSlice != nil:
+					t.Errorf("unexpected time-sliced replica %+v of GPU %s", *gpu.timeSlice, uuid)
+				case timeSliced && gpu.timeSlice == nil:
+					t.Errorf("expected time-sliced replica %d of GPU %s", index, uuid)
+				case timeSliced && (gpu.timeSlice.Index != index || gpu.timeSlice.Replicas != tc.classParams.TimeSlicing.Replicas):
+					t.Errorf("expected time-sliced replica %d of GPU %s, got %+v", index, uuid, *gpu.timeSlice)
+				}
+			}
+		})
+	}
+}
+
+func TestFindAllocatableGPUs(t *testing.T) {
+	testCases := []struct {
+		name        string
+		claimUID    string
+		claimParams interface{}
+		classParams interface{}
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "held GPU is preferred",
+			claimUID:    testClaimUID,
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-7"},
+		},
+		{
+			name:        "held GPU first",
+			claimUID:    testClaimUID,
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 2},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-7", "GPU-5"},
+		},
+		{
+			name:        "insufficient whole GPUs",
+			claimUID:    testClaimUID,
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 3},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			wantErr:     "insufficient GPUs",
+		},
+		{
+			name:        "insufficient memory",
+			claimUID:    testClaimUID,
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1, Memory: resource.MustParse("48Gi")},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			wantErr:     "insufficient GPUs",
+		},
+		{
+			name:        "partition",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1, PartitionProfile: "1g.10gb"},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-4-P1"},
+		},
+		{
+			name:        "shared",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 2, AccessMode: gpuv1alpha1.AccessModeShared},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-1", "GPU-5"},
+		},
+		{
+			name:        "fractional claims are packed",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1, Sharing: gpuv1alpha1.SharingPolicyFractional, Memory: resource.MustParse("8Gi")},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-2"},
+		},
+		{
+			name:        "fractional claim larger than free memory",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1, Sharing: gpuv1alpha1.SharingPolicyFractional, Memory: resource.MustParse("20Gi")},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			want:        []string{"GPU-5"},
+		},
+		{
+			name:        "time-sliced",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 2},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{TimeSlicing: &gpuv1alpha1.TimeSlicing{Replicas: 4}},
+			want:        []string{"GPU-3", "GPU-5"},
+		},
+		{
+			name:        "fractional claim of time-sliced class",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1, Sharing: gpuv1alpha1.SharingPolicyFractional, Memory: resource.MustParse("8Gi")},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{TimeSlicing: &gpuv1alpha1.TimeSlicing{Replicas: 4}},
+			wantErr:     "time-sliced class",
+		},
+		{
+			name:        "device selectors",
+			claimUID:    "new",
+			claimParams: &gpuv1alpha1.GPURequirementsSpec{Count: 1},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{DeviceSelector: []gpuv1alpha1.DeviceSelector{{Vendor: "amd"}}},
+			wantErr:     "insufficient GPUs",
+		},
+		{
+			name:        "unsupported claim parameters",
+			claimUID:    "new",
+			claimParams: &resourcev1alpha2.ResourceClaimParameters{},
+			classParams: &gpuv1alpha1.GPUClassParametersSpec{},
+			wantErr:     "unsupported claim parameters",
+		},
+	}
+
+	d := &driver{log: zlog.Nop()}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claimAllocation := testClaimAllocation(tc.claimUID, tc.claimParams, tc.classParams)
+			selected, err := d.findAllocatableGPUs(testNodeDevices(), claimAllocation, "node-0")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to find allocatable GPUs: %v", err)
+			}
+
+			if len(selected) != len(tc.want) {
+				t.Fatalf("expected %d GPUs, got %d", len(tc.want), len(selected))
+			}
+			for i, gpu := range selected {
+				if gpu.UUID != tc.want[i] {
+					t.Errorf("expected GPU %s at %d, got %s", tc.want[i], i, gpu.UUID)
+				}
+			}
+		})
+	}
+}
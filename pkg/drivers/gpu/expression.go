@@ -0,0 +1,150 @@
+package gpu
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// deviceVar is the name of the CEL variable that holds the attributes of the
+// device being evaluated.
+const deviceVar = "device"
+
+// expressionEvaluator compiles and evaluates the CEL expressions of device
+// selectors. Compiled programs are cached by their expression.
+type expressionEvaluator struct {
+	env *cel.Env
+
+	mu       sync.RWMutex
+	programs map[string]cel.Program
+}
+
+func newExpressionEvaluator() (*expressionEvaluator, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(deviceVar, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Function("quantity",
+			cel.Overload("quantity_string",
+				[]*cel.Type{cel.StringType},
+				cel.IntType,
+				cel.UnaryBinding(quantity),
+			),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	return &expressionEvaluator{
+		env:      env,
+		programs: map[string]cel.Program{},
+	}, nil
+}
+
+// compile compiles the expression into a CEL program. It returns an error if
+// the expression is invalid or doesn't evaluate to a boolean.
+func (e *expressionEvaluator) compile(expression string) (cel.Program, error) {
+	e.mu.RLock()
+	program, exists := e.programs[expression]
+	e.mu.RUnlock()
+	if exists {
+		return program, nil
+	}
+
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expression, issues.Err())
+	}
+
+	// device attributes are dynamically typed, so dyn results are checked at
+	// evaluation time
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("expression %q must evaluate to bool, not %s", expression, outputType)
+	}
+
+	program, err := e.env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build program for expression %q: %w", expression, err)
+	}
+
+	e.mu.Lock()
+	e.programs[expression] = program
+	e.mu.Unlock()
+
+	return program, nil
+}
+
+// eval evaluates the expression against the attributes of the GPU.
+func (e *expressionEvaluator) eval(expression string, gpu *gpuv1alpha2.GPUDevice) (bool, error) {
+	program, err := e.compile(expression)
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := program.Eval(map[string]interface{}{
+		deviceVar: deviceAttributes(gpu),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression %q against GPU %s: %w", expression, gpu.UUID, err)
+	}
+
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to non-bool value %v", expression, out.Value())
+	}
+
+	return matched, nil
+}
+
+// deviceAttributes returns the attributes of the GPU that are exposed to CEL
+// expressions. Memory is expressed in bytes. The topology and partition
+// attributes are only set if they are known, so expressions should guard them
+// with has().
+func deviceAttributes(gpu *gpuv1alpha2.GPUDevice) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"uuid":        gpu.UUID,
+		"productName": gpu.ProductName,
+		"vendor":      gpu.Vendor,
+		"memory":      gpu.Memory.Value(),
+	}
+
+	if topology := gpu.Topology; topology != nil {
+		if topology.NUMANode != nil {
+			attributes["numaNode"] = *topology.NUMANode
+		}
+		if topology.PCIeRoot != "" {
+			attributes["pcieRoot"] = topology.PCIeRoot
+		}
+		if topology.InterconnectGroup != "" {
+			attributes["interconnectGroup"] = topology.InterconnectGroup
+		}
+	}
+
+	if partition := gpu.Partition; partition != nil {
+		attributes["parentUUID"] = partition.ParentUUID
+		attributes["partitionProfile"] = partition.Profile
+		attributes["partitionSlices"] = partition.Slices
+	}
+
+	return attributes
+}
+
+// quantity converts a Kubernetes quantity string like "16Gi" into its integer
+// value, so that it can be compared with numeric device attributes.
+func quantity(arg ref.Val) ref.Val {
+	str, ok := arg.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(arg)
+	}
+
+	q, err := resource.ParseQuantity(string(str))
+	if err != nil {
+		return types.NewErr("invalid quantity %q: %v", str, err)
+	}
+
+	return types.Int(q.Value())
+}
@@ -0,0 +1,201 @@
+package gpu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreclientset "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// reclaimedDevices counts the devices freed from the allocations of claims that
+// no longer exist.
+var reclaimedDevices = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "dra",
+	Subsystem: "gpu",
+	Name:      "orphaned_devices_reclaimed_total",
+	Help:      "Number of devices reclaimed from the allocations of deleted resource claims.",
+}, []string{"node", "state"})
+
+func init() {
+	prometheus.MustRegister(reclaimedDevices)
+}
+
+// CollectOrphanedAllocations periodically frees the device allocations of the
+// claims that no longer exist, e.g. because they were force-deleted, or
+// deleted while the controller was down. It blocks until the context is
+// cancelled.
+func (d *driver) CollectOrphanedAllocations(ctx context.Context, coreClientSets coreclientset.Interface, period time.Duration) {
+	d.log.Info().Dur("period", period).Msg("starting orphaned allocation collector")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := d.collectOrphanedAllocations(ctx, coreClientSets); err != nil {
+			d.log.Error().Err(err).Msg("failed to collect orphaned allocations")
+		}
+	}, period)
+}
+
+// collectOrphanedAllocations frees the allocations of the claims that aren't
+// found in the API server. The NodeGPUSlices are read before the claims are
+// listed, so that the allocations of new claims are never mistaken for
+// orphans. Nodes with write conflicts are retried on the next collection.
+func (d *driver) collectOrphanedAllocations(ctx context.Context, coreClientSets coreclientset.Interface) error {
+	nodes, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	claims, err := coreClientSets.ResourceV1alpha2().ResourceClaims(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	live := map[string]bool{}
+	for _, claim := range claims.Items {
+		live[string(claim.GetUID())] = true
+	}
+
+	var errs error
+	for _, nodeDevices := range nodes {
+		orphaned := map[string][]*gpuv1alpha2.DeviceAllocation{}
+		for claimUID, allocations := range nodeDevices.Status.Allocations {
+			if !live[claimUID] {
+				orphaned[claimUID] = allocations
+			}
+		}
+
+		if len(orphaned) == 0 {
+			continue
+		}
+
+		if err := d.removeAllocations(ctx, nodeDevices, orphaned); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		for claimUID, allocations := range orphaned {
+			for _, allocation := range allocations {
+				d.log.Info().
+					Str("node", nodeDevices.GetName()).
+					Str("claimUID", claimUID).
+					Str("deviceUUID", allocation.Device.UUID).
+					Str("state", string(allocation.State)).
+					Msg("reclaimed device of orphaned allocation")
+				reclaimedDevices.WithLabelValues(nodeDevices.GetName(), string(allocation.State)).Inc()
+			}
+		}
+	}
+
+	return errs
+}
+
+// SweepDeletedNodes periodically deletes the NodeGPUSlices of the nodes that no
+// longer exist, releasing their allocations. The NodeGPUSlices are owned by
+// their nodes, so they're usually garbage-collected with them. The sweeper
+// catches the objects created without an owner reference, and the ones whose
+// plugin never came back. It blocks until the context is cancelled.
+func (d *driver) SweepDeletedNodes(ctx context.Context, coreClientSets coreclientset.Interface, nodeLister corelisters.NodeLister, period time.Duration) {
+	d.log.Info().Dur("period", period).Msg("starting deleted node sweeper")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := d.sweepDeletedNodes(ctx, coreClientSets, nodeLister); err != nil {
+			d.log.Error().Err(err).Msg("failed to sweep deleted nodes")
+		}
+	}, period)
+}
+
+// sweepDeletedNodes deletes the NodeGPUSlices whose nodes aren't found. The
+// nodes are looked up in the informer cache first, and the missing ones are
+// confirmed against the API server before their objects are deleted, so that
+// new nodes that the cache hasn't seen yet are never mistaken for deleted ones.
+func (d *driver) sweepDeletedNodes(ctx context.Context, coreClientSets coreclientset.Interface, nodeLister corelisters.NodeLister) error {
+	nodes, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, nodeDevices := range nodes {
+		nodeName := nodeDevices.GetName()
+		_, err := nodeLister.Get(nodeName)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		_, err = coreClientSets.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		// the UID precondition guards against deleting the object of a new node
+		// with the same name
+		uid := nodeDevices.GetUID()
+		deleteOpts := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}}
+		if err := d.clientsets.GpuV1alpha2().NodeGPUSlices(d.namespace).Delete(ctx, nodeName, deleteOpts); err != nil && !apierrors.IsNotFound(err) {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		d.log.Info().Str("node", nodeName).Msg("deleted NodeGPUSlices of deleted node")
+		for claimUID, allocations := range nodeDevices.Status.Allocations {
+			for _, allocation := range allocations {
+				d.log.Info().
+					Str("node", nodeName).
+					Str("claimUID", claimUID).
+					Str("deviceUUID", allocation.Device.UUID).
+					Str("state", string(allocation.State)).
+					Msg("released device of deleted node")
+			}
+		}
+	}
+
+	return errs
+}
+
+// removeAllocations removes the claims' allocations and node suitability
+// entries from the NodeGPUSlices status with a JSON merge patch. Unlike
+// server-side apply, the patch also removes the prepared allocations owned by
+// the kubelet plugin. The resource version guards against removing the
+// allocations from a stale copy.
+func (d *driver) removeAllocations(ctx context.Context, cached *gpuv1alpha2.NodeGPUSlices, claimAllocations map[string][]*gpuv1alpha2.DeviceAllocation) error {
+	nodeDevices := cached.DeepCopy()
+	removed := map[string]interface{}{}
+	for claimUID := range claimAllocations {
+		delete(nodeDevices.Status.Allocations, claimUID)
+		delete(nodeDevices.Status.NodeSuitability, claimUID)
+		removed[claimUID] = nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": nodeDevices.GetResourceVersion(),
+		},
+		"status": map[string]interface{}{
+			"allocations":     removed,
+			"nodeSuitability": removed,
+			"allocatedCount":  nodeDevices.Status.AllocatedDeviceCount(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	patchOpts := metav1.PatchOptions{FieldManager: fieldManager}
+	_, err = d.clientsets.GpuV1alpha2().NodeGPUSlices(d.namespace).Patch(ctx, nodeDevices.GetName(), types.MergePatchType, patch, patchOpts, "status")
+	return err
+}
@@ -0,0 +1,117 @@
+package gpu
+
+import (
+	"context"
+	"testing"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func resourceClaim(namespace, name, uid string) *resourcev1alpha2.ResourceClaim {
+	return &resourcev1alpha2.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(uid)},
+	}
+}
+
+func TestCollectOrphanedAllocations(t *testing.T) {
+	var (
+		nodeDevices = freeNodeDevices("node-0")
+		gpus        = nodeDevices.Spec.AllocatableGPUs
+		otherNode   = freeNodeDevices("node-1")
+	)
+
+	// the claim named "recreated" was deleted and recreated with a new UID,
+	// while its old allocation was left behind
+	nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		"live-uid":    {deviceAllocation("live-uid", gpus[0], gpuv1alpha2.DeviceAllocationStatePrepared)},
+		"deleted-uid": {deviceAllocation("deleted-uid", gpus[1], gpuv1alpha2.DeviceAllocationStateAllocated)},
+		"old-uid":     {heldAllocation("old-uid", gpus[1], testNow)},
+		"new-uid":     {heldAllocation("new-uid", gpus[1], testNow)},
+	}
+	nodeDevices.Status.NodeSuitability = map[string]gpuv1alpha2.NodeSuitability{
+		"old-uid": gpuv1alpha2.NodeSuitabilitySuitable,
+		"new-uid": gpuv1alpha2.NodeSuitabilitySuitable,
+	}
+	nodeDevices.Status.AllocatedCount = 2
+	otherNode.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		"other-uid": {deviceAllocation("other-uid", otherNode.Spec.AllocatableGPUs[0], gpuv1alpha2.DeviceAllocationStateAllocated)},
+	}
+	otherNode.Status.AllocatedCount = 1
+
+	d, clientsets, _, _ := newTestDriver(t, nodeDevices, otherNode)
+	coreClientSets := corefake.NewSimpleClientset(
+		resourceClaim("default", "live", "live-uid"),
+		resourceClaim("default", "recreated", "new-uid"),
+		resourceClaim("other", "live", "other-uid"),
+	)
+
+	if err := d.collectOrphanedAllocations(context.Background(), coreClientSets); err != nil {
+		t.Fatalf("failed to collect orphaned allocations: %v", err)
+	}
+
+	for _, action := range clientsets.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && patch.GetName() != "node-0" {
+			t.Errorf("expected only node-0 to be patched, got %s", patch.GetName())
+		}
+	}
+
+	collected, err := clientsets.GpuV1alpha2().NodeGPUSlices(testNamespace).Get(context.Background(), "node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := collected.Status
+	for _, claimUID := range []string{"deleted-uid", "old-uid"} {
+		if _, exists := status.Allocations[claimUID]; exists {
+			t.Errorf("expected the allocations of claim %s to be removed", claimUID)
+		}
+		if _, exists := status.NodeSuitability[claimUID]; exists {
+			t.Errorf("expected the node suitability of claim %s to be removed", claimUID)
+		}
+	}
+	for _, claimUID := range []string{"live-uid", "new-uid"} {
+		if len(status.Allocations[claimUID]) != 1 {
+			t.Errorf("expected the allocations of claim %s to be kept", claimUID)
+		}
+	}
+	if status.NodeSuitability["new-uid"] != gpuv1alpha2.NodeSuitabilitySuitable {
+		t.Error("expected the node suitability of the recreated claim to be kept")
+	}
+	if status.AllocatedCount != 1 {
+		t.Errorf("expected allocated count 1, got %d", status.AllocatedCount)
+	}
+
+	untouched, err := clientsets.GpuV1alpha2().NodeGPUSlices(testNamespace).Get(context.Background(), "node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untouched.Status.Allocations["other-uid"]) != 1 {
+		t.Error("expected the allocations of the claim in the other namespace to be kept")
+	}
+}
+
+func TestCollectOrphanedAllocationsWithoutClaims(t *testing.T) {
+	nodeDevices := freeNodeDevices("node-0")
+	nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		"deleted-uid": {deviceAllocation("deleted-uid", nodeDevices.Spec.AllocatableGPUs[0], gpuv1alpha2.DeviceAllocationStatePrepared)},
+	}
+	nodeDevices.Status.AllocatedCount = 1
+	d, clientsets, _, _ := newTestDriver(t, nodeDevices)
+
+	if err := d.collectOrphanedAllocations(context.Background(), corefake.NewSimpleClientset()); err != nil {
+		t.Fatalf("failed to collect orphaned allocations: %v", err)
+	}
+
+	collected, err := clientsets.GpuV1alpha2().NodeGPUSlices(testNamespace).Get(context.Background(), "node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collected.Status.Allocations) != 0 || collected.Status.AllocatedCount != 0 {
+		t.Errorf("expected the prepared allocation of the deleted claim to be removed, got %+v", collected.Status)
+	}
+}
@@ -0,0 +1,114 @@
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// holdReapsPerTTL is the number of times the holds are reaped per hold TTL, so
+// that expired holds outlive their TTL by at most a fraction of it.
+const holdReapsPerTTL = 4
+
+// ReapExpiredHolds periodically releases the temporary holds that were placed
+// on devices more than holdTTL ago. It blocks until the context is cancelled.
+func (d *driver) ReapExpiredHolds(ctx context.Context, holdTTL time.Duration) error {
+	period := holdTTL / holdReapsPerTTL
+	if period <= 0 {
+		return fmt.Errorf("invalid hold TTL %s", holdTTL)
+	}
+
+	d.log.Info().Dur("holdTTL", holdTTL).Dur("period", period).Msg("starting hold reaper")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := d.reapExpiredHolds(ctx, holdTTL); err != nil {
+			d.log.Error().Err(err).Msg("failed to reap expired holds")
+		}
+	}, period)
+	return nil
+}
+
+// reapExpiredHolds releases the holds that were placed more than holdTTL ago,
+// on all the nodes.
+func (d *driver) reapExpiredHolds(ctx context.Context, holdTTL time.Duration) error {
+	now := d.clock.Now()
+	return d.removeHolds(ctx, "", func(_ string, allocation *gpuv1alpha2.DeviceAllocation) bool {
+		return allocation.HoldTimestamp != nil && now.Sub(allocation.HoldTimestamp.Time) > holdTTL
+	})
+}
+
+// releaseHolds releases the holds placed for the claim on all the nodes, except
+// the given node.
+func (d *driver) releaseHolds(ctx context.Context, claimUID, exceptNode string) error {
+	return d.removeHolds(ctx, exceptNode, func(uid string, _ *gpuv1alpha2.DeviceAllocation) bool {
+		return uid == claimUID
+	})
+}
+
+// removeHolds removes the holds that satisfy shouldRemove from the
+// NodeGPUSlices of all the nodes, except the given node.
+func (d *driver) removeHolds(
+	ctx context.Context,
+	exceptNode string,
+	shouldRemove func(claimUID string, allocation *gpuv1alpha2.DeviceAllocation) bool) error {
+	nodes, err := d.nodeDevicesLister.NodeGPUSlices(d.namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, cached := range nodes {
+		nodeName := cached.GetName()
+		if nodeName == exceptNode {
+			continue
+		}
+
+		// skip nodes without matching holds, to avoid unnecessary writes
+		if !filterHolds(cached.DeepCopy(), shouldRemove) {
+			continue
+		}
+
+		d.log.Info().Str("node", nodeName).Msg("releasing GPU holds...")
+		if err := d.updateNodeDevices(ctx, nodeName, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+			filterHolds(nodeDevices, shouldRemove)
+			return nil
+		}); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// filterHolds removes the holds that satisfy shouldRemove from nodeDevices.
+// The node suitability entries of the claims that are left without any
+// allocations on the node are removed with them. It returns true if any holds
+// are removed.
+func filterHolds(
+	nodeDevices *gpuv1alpha2.NodeGPUSlices,
+	shouldRemove func(claimUID string, allocation *gpuv1alpha2.DeviceAllocation) bool) bool {
+	removed := false
+	for claimUID, allocations := range nodeDevices.Status.Allocations {
+		remaining := []*gpuv1alpha2.DeviceAllocation{}
+		for _, allocation := range allocations {
+			if allocation.State == gpuv1alpha2.DeviceAllocationStateHold && shouldRemove(claimUID, allocation) {
+				removed = true
+				continue
+			}
+			remaining = append(remaining, allocation)
+		}
+
+		if len(remaining) == 0 {
+			delete(nodeDevices.Status.Allocations, claimUID)
+			delete(nodeDevices.Status.NodeSuitability, claimUID)
+			continue
+		}
+		nodeDevices.Status.Allocations[claimUID] = remaining
+	}
+
+	return removed
+}
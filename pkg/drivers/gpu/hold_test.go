@@ -0,0 +1,171 @@
+package gpu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	dractrl "k8s.io/dynamic-resource-allocation/controller"
+)
+
+// freeNodeDevices returns the NodeGPUSlices of a node with two free GPUs.
+func freeNodeDevices(nodeName string) *gpuv1alpha2.NodeGPUSlices {
+	return &gpuv1alpha2.NodeGPUSlices{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Spec: gpuv1alpha2.NodeGPUSlicesSpec{
+			AllocatableGPUs: []*gpuv1alpha2.GPUDevice{gpuDevice("GPU-0", "40Gi"), gpuDevice("GPU-1", "40Gi")},
+		},
+	}
+}
+
+// heldAllocation returns the hold of the GPU for the claim, placed at the given
+// time.
+func heldAllocation(claimUID string, gpu *gpuv1alpha2.GPUDevice, holdTime time.Time) *gpuv1alpha2.DeviceAllocation {
+	allocation := deviceAllocation(claimUID, gpu, gpuv1alpha2.DeviceAllocationStateHold)
+	holdTimestamp := metav1.NewTime(holdTime)
+	allocation.HoldTimestamp = &holdTimestamp
+	return allocation
+}
+
+func TestUnsuitableNodesPlacesHolds(t *testing.T) {
+	d, clientsets, _, fakeClock := newTestDriver(t, freeNodeDevices("node-0"))
+
+	claim := testClaimAllocation(testClaimUID, &gpuv1alpha1.GPURequirementsSpec{Count: 1}, &gpuv1alpha1.GPUClassParametersSpec{})
+	if err := d.UnsuitableNodes(context.Background(), &corev1.Pod{}, []*dractrl.ClaimAllocation{claim}, []string{"node-0", "node-1"}); err != nil {
+		t.Fatalf("failed to check unsuitable nodes: %v", err)
+	}
+
+	// node-1 has no NodeGPUSlices
+	if len(claim.UnsuitableNodes) != 1 || claim.UnsuitableNodes[0] != "node-1" {
+		t.Errorf("expected only node-1 to be unsuitable, got %v", claim.UnsuitableNodes)
+	}
+
+	applied := appliedStatuses(t, clientsets)["node-0"]
+	if applied == nil {
+		t.Fatal("expected the NodeGPUSlices of node-0 to be applied")
+	}
+	if suitability := applied.Status.NodeSuitability[testClaimUID]; suitability != gpuv1alpha2.NodeSuitabilitySuitable {
+		t.Errorf("expected node-0 to be suitable, got %q", suitability)
+	}
+
+	holds := applied.Status.Allocations[testClaimUID]
+	if len(holds) != 1 {
+		t.Fatalf("expected 1 hold, got %d", len(holds))
+	}
+	if holds[0].State != gpuv1alpha2.DeviceAllocationStateHold || holds[0].Device.UUID != "GPU-0" {
+		t.Errorf("expected hold on GPU-0, got %s allocation of %s", holds[0].State, holds[0].Device.UUID)
+	}
+	if holds[0].HoldTimestamp == nil || !holds[0].HoldTimestamp.Time.Equal(fakeClock.Now()) {
+		t.Errorf("expected hold timestamp %s, got %v", fakeClock.Now(), holds[0].HoldTimestamp)
+	}
+}
+
+func TestUnsuitableNodesRefreshesHolds(t *testing.T) {
+	nodeDevices := freeNodeDevices("node-0")
+	nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		testClaimUID: {heldAllocation(testClaimUID, nodeDevices.Spec.AllocatableGPUs[1], testNow)},
+	}
+	nodeDevices.Status.NodeSuitability = map[string]gpuv1alpha2.NodeSuitability{
+		testClaimUID: gpuv1alpha2.NodeSuitabilitySuitable,
+	}
+	d, clientsets, indexer, fakeClock := newTestDriver(t, nodeDevices)
+
+	// the claim is re-evaluated before its hold expires
+	holdTTL := time.Minute
+	fakeClock.Step(45 * time.Second)
+	claim := testClaimAllocation(testClaimUID, &gpuv1alpha1.GPURequirementsSpec{Count: 1}, &gpuv1alpha1.GPUClassParametersSpec{})
+	if err := d.UnsuitableNodes(context.Background(), &corev1.Pod{}, []*dractrl.ClaimAllocation{claim}, []string{"node-0"}); err != nil {
+		t.Fatalf("failed to check unsuitable nodes: %v", err)
+	}
+	if len(claim.UnsuitableNodes) != 0 {
+		t.Errorf("expected node-0 to be suitable, got unsuitable nodes %v", claim.UnsuitableNodes)
+	}
+
+	applied := appliedStatuses(t, clientsets)["node-0"]
+	if applied == nil {
+		t.Fatal("expected the NodeGPUSlices of node-0 to be applied")
+	}
+
+	holds := applied.Status.Allocations[testClaimUID]
+	if len(holds) != 1 || holds[0].Device.UUID != "GPU-1" {
+		t.Fatalf("expected the hold on GPU-1 to be kept, got %+v", holds)
+	}
+	if holds[0].HoldTimestamp == nil || !holds[0].HoldTimestamp.Time.Equal(fakeClock.Now()) {
+		t.Errorf("expected hold timestamp to be refreshed to %s, got %v", fakeClock.Now(), holds[0].HoldTimestamp)
+	}
+
+	// the refreshed hold outlives the TTL of the original hold
+	refreshed := nodeDevices.DeepCopy()
+	refreshed.Status.Allocations = applied.Status.Allocations
+	if err := indexer.Update(refreshed); err != nil {
+		t.Fatal(err)
+	}
+	clientsets.ClearActions()
+
+	fakeClock.Step(45 * time.Second)
+	if err := d.reapExpiredHolds(context.Background(), holdTTL); err != nil {
+		t.Fatalf("failed to reap expired holds: %v", err)
+	}
+	if len(clientsets.Actions()) != 0 {
+		t.Errorf("expected the refreshed hold not to be reaped, got actions %v", clientsets.Actions())
+	}
+}
+
+func TestReapExpiredHolds(t *testing.T) {
+	var (
+		holdTTL     = time.Minute
+		nodeDevices = freeNodeDevices("node-0")
+		gpus        = nodeDevices.Spec.AllocatableGPUs
+		otherNode   = freeNodeDevices("node-1")
+	)
+	nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		"expired":   {heldAllocation("expired", gpus[0], testNow.Add(-2*holdTTL))},
+		"live":      {heldAllocation("live", gpus[1], testNow.Add(-holdTTL/2))},
+		"allocated": {deviceAllocation("allocated", gpus[1], gpuv1alpha2.DeviceAllocationStateAllocated)},
+	}
+	nodeDevices.Status.NodeSuitability = map[string]gpuv1alpha2.NodeSuitability{
+		"expired": gpuv1alpha2.NodeSuitabilitySuitable,
+		"live":    gpuv1alpha2.NodeSuitabilitySuitable,
+	}
+	otherNode.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{
+		"live": {heldAllocation("live", otherNode.Spec.AllocatableGPUs[0], testNow.Add(-holdTTL/2))},
+	}
+	d, clientsets, _, _ := newTestDriver(t, nodeDevices, otherNode)
+
+	if err := d.reapExpiredHolds(context.Background(), holdTTL); err != nil {
+		t.Fatalf("failed to reap expired holds: %v", err)
+	}
+
+	applied := appliedStatuses(t, clientsets)
+	if _, exists := applied["node-1"]; exists {
+		t.Error("expected node-1 without expired holds not to be updated")
+	}
+
+	status := applied["node-0"].Status
+	if _, exists := status.Allocations["expired"]; exists {
+		t.Error("expected the expired hold to be released")
+	}
+	if _, exists := status.NodeSuitability["expired"]; exists {
+		t.Error("expected the node suitability of the expired hold to be removed")
+	}
+	if len(status.Allocations["live"]) != 1 || status.NodeSuitability["live"] != gpuv1alpha2.NodeSuitabilitySuitable {
+		t.Errorf("expected the live hold to be kept, got %+v", status.Allocations["live"])
+	}
+	if len(status.Allocations["allocated"]) != 1 {
+		t.Error("expected the allocation to be kept")
+	}
+	if status.AllocatedCount != 1 {
+		t.Errorf("expected allocated count 1, got %d", status.AllocatedCount)
+	}
+}
+
+func TestReapExpiredHoldsInvalidTTL(t *testing.T) {
+	d, _, _, _ := newTestDriver(t)
+	if err := d.ReapExpiredHolds(context.Background(), time.Nanosecond); err == nil {
+		t.Error("expected an error for a hold TTL shorter than the reaping period")
+	}
+}
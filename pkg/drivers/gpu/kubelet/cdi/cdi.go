@@ -3,19 +3,39 @@ package cdi
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	cdiapi "github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
 	cdispec "github.com/container-orchestrated-devices/container-device-interface/specs-go"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
-	cdiVendor = "resources.ihcsim"
-	cdiClass  = "gpu"
+	cdiVendor         = "resources.ihcsim"
+	cdiClass          = "gpu"
+	cdiPartitionClass = "gpu-partition"
+
+	envUUID              = "DEVICE_UUID"
+	envProductName       = "DEVICE_PRODUCT_NAME"
+	envVendorName        = "DEVICE_VENDOR_NAME"
+	envMemory            = "DEVICE_MEMORY"
+	envNUMANode          = "DEVICE_NUMA_NODE"
+	envPCIeRoot          = "DEVICE_PCIE_ROOT"
+	envInterconnectGroup = "DEVICE_INTERCONNECT_GROUP"
+	envTimeSliceIndex    = "DEVICE_TIME_SLICE_INDEX"
+	envTimeSliceReplicas = "DEVICE_TIME_SLICE_REPLICAS"
+	envParentUUID        = "DEVICE_PARENT_UUID"
+	envPartitionProfile  = "DEVICE_PARTITION_PROFILE"
+	envPartitionSlices   = "DEVICE_PARTITION_SLICES"
 )
 
 var (
-	cdiKind = cdiVendor + "/" + cdiClass
+	cdiKind          = cdiVendor + "/" + cdiClass
+	cdiPartitionKind = cdiVendor + "/" + cdiPartitionClass
 
 	registry cdiapi.Registry
 	once     sync.Once
@@ -26,59 +46,291 @@ type GPUDevice struct {
 	UUID        string
 	ProductName string
 	VendorName  string
+	Memory      resource.Quantity
+
+	// NUMANode is nil if the NUMA node of the device is unknown.
+	NUMANode          *int
+	PCIeRoot          string
+	InterconnectGroup string
+
+	// TimeSliceReplicas is 0 if the device isn't time-sliced. Otherwise, the
+	// workload is given the time-sliced replica at TimeSliceIndex.
+	TimeSliceIndex    int
+	TimeSliceReplicas int
+
+	// ParentUUID is empty if the device isn't a partition of a physical GPU.
+	ParentUUID       string
+	PartitionProfile string
+	PartitionSlices  int
+}
+
+// class returns the CDI class of the device. Partitions are published under
+// their own class, so that their names never collide with whole GPUs.
+func (gpu *GPUDevice) class() string {
+	if gpu.ParentUUID != "" {
+		return cdiPartitionClass
+	}
+	return cdiClass
 }
 
+// InitRegistryOnce initializes the CDI registry with the CDI root. The registry
+// doesn't refresh itself; it's refreshed whenever devices are discovered.
 func InitRegistryOnce(cdiRoot string) {
 	once.Do(func() {
-		registry = cdiapi.GetRegistry(cdiapi.WithSpecDirs(cdiRoot))
+		registry = cdiapi.GetRegistry(
+			cdiapi.WithSpecDirs(cdiRoot),
+			cdiapi.WithAutoRefresh(false))
 	})
 }
 
+// DiscoverFromSpecs returns the GPUs declared by the node's CDI specs. Specs
+// of other kinds of the vendor are skipped. Spec files that can't be loaded and
+// devices that can't be parsed are reported in the returned error, along with
+// the GPUs that could be discovered, so that one malformed spec doesn't hide
+// the other devices of the node.
 func DiscoverFromSpecs() ([]*GPUDevice, error) {
+	// the refresh errors are the spec errors, which are reported by Specs()
+	_ = registry.Refresh()
+
 	specs, err := Specs()
-	if err != nil {
-		return nil, err
-	}
+	errs := []error{err}
 
-	var gpuDevices []*GPUDevice
+	gpuDevices := []*GPUDevice{}
 	for _, spec := range specs {
+		if spec.Kind != cdiKind && spec.Kind != cdiPartitionKind {
+			continue
+		}
+
 		for _, device := range spec.Devices {
-			gpuDevices = append(gpuDevices, &GPUDevice{
-				UUID:        device.Name,
-				ProductName: device.ContainerEdits.Env[1],
-				VendorName:  device.ContainerEdits.Env[2],
-			})
+			gpuDevice, err := parseDevice(spec, device)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid CDI device %s in %s: %w", device.Name, spec.GetPath(), err))
+				continue
+			}
+			gpuDevices = append(gpuDevices, gpuDevice)
+		}
+	}
+
+	return gpuDevices, errors.Join(errs...)
+}
+
+// parseDevice returns the GPU declared by the CDI device. The device must
+// declare its product and vendor names, and partitions must declare their
+// parent and profile.
+func parseDevice(spec *cdiapi.Spec, device cdispec.Device) (*GPUDevice, error) {
+	attrs := deviceAttributes(spec, device)
+	if uuid, found := attrs[envUUID]; found && uuid != device.Name {
+		return nil, fmt.Errorf("%s %q doesn't match the device name", envUUID, uuid)
+	}
+
+	var errs []error
+	for _, key := range []string{envProductName, envVendorName} {
+		if attrs[key] == "" {
+			errs = append(errs, fmt.Errorf("missing %s", key))
+		}
+	}
+
+	memory, err := deviceMemory(attrs)
+	errs = append(errs, err)
+
+	numaNode, err := deviceNUMANode(attrs)
+	errs = append(errs, err)
+
+	gpuDevice := &GPUDevice{
+		UUID:              device.Name,
+		ProductName:       attrs[envProductName],
+		VendorName:        attrs[envVendorName],
+		Memory:            memory,
+		NUMANode:          numaNode,
+		PCIeRoot:          attrs[envPCIeRoot],
+		InterconnectGroup: attrs[envInterconnectGroup],
+	}
+
+	if spec.Kind == cdiPartitionKind {
+		errs = append(errs, devicePartition(attrs, gpuDevice))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return gpuDevice, nil
+}
+
+// deviceAttributes returns the attributes of the device, as declared by the
+// KEY=VALUE env vars in the container edits of its spec and of the device
+// itself. The device's env vars take precedence over the spec's, so that the
+// attributes shared by all the devices can be declared once per spec.
+func deviceAttributes(spec *cdiapi.Spec, device cdispec.Device) map[string]string {
+	attrs := map[string]string{}
+	for _, edits := range []cdispec.ContainerEdits{spec.ContainerEdits, device.ContainerEdits} {
+		for _, env := range edits.Env {
+			if key, value, found := strings.Cut(env, "="); found {
+				attrs[key] = value
+			}
+		}
+	}
+	return attrs
+}
+
+// deviceMemory returns the memory capacity of the device, as declared by the
+// DEVICE_MEMORY attribute. A zero quantity is returned if the device doesn't
+// declare its memory.
+func deviceMemory(attrs map[string]string) (resource.Quantity, error) {
+	value, found := attrs[envMemory]
+	if !found {
+		return resource.Quantity{}, nil
+	}
+
+	memory, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid memory %q: %w", value, err)
+	}
+	return memory, nil
+}
+
+// deviceNUMANode returns the NUMA node of the device, as declared by the
+// DEVICE_NUMA_NODE attribute. nil is returned if the device doesn't declare its
+// NUMA node.
+func deviceNUMANode(attrs map[string]string) (*int, error) {
+	value, found := attrs[envNUMANode]
+	if !found {
+		return nil, nil
+	}
+
+	numaNode, err := strconv.Atoi(value)
+	if err != nil || numaNode < 0 {
+		return nil, fmt.Errorf("invalid NUMA node %q", value)
+	}
+	return &numaNode, nil
+}
+
+// devicePartition sets the partition fields of the GPU from the
+// DEVICE_PARENT_UUID, DEVICE_PARTITION_PROFILE and DEVICE_PARTITION_SLICES
+// attributes. Partitions must declare their parent and profile.
+func devicePartition(attrs map[string]string, gpu *GPUDevice) error {
+	parentUUID, profile := attrs[envParentUUID], attrs[envPartitionProfile]
+	if parentUUID == "" || profile == "" {
+		return fmt.Errorf("partitions must declare their parent and profile")
+	}
+
+	slices := 1
+	if value, found := attrs[envPartitionSlices]; found {
+		var err error
+		slices, err = strconv.Atoi(value)
+		if err != nil || slices < 1 {
+			return fmt.Errorf("invalid partition slices %q", value)
 		}
 	}
 
-	return gpuDevices, nil
+	gpu.ParentUUID = parentUUID
+	gpu.PartitionProfile = profile
+	gpu.PartitionSlices = slices
+	return nil
 }
 
-func DeviceQualifiedName(gpu *GPUDevice) string {
-	return cdiapi.QualifiedName(cdiVendor, cdiClass, gpu.UUID)
+// DeviceQualifiedName returns the qualified name of the device in the transient
+// CDI spec of the claim. Transient devices are named after the claim, so that
+// they never conflict with the devices of the node's CDI specs, nor with the
+// devices of other claims.
+func DeviceQualifiedName(claimUID string, gpu *GPUDevice) string {
+	return cdiapi.QualifiedName(cdiVendor, gpu.class(), transientDeviceName(claimUID, gpu))
 }
 
+func transientDeviceName(claimUID string, gpu *GPUDevice) string {
+	return claimUID + "-" + gpu.UUID
+}
+
+// IsTransientSpec returns true if the CDI spec file at path is a transient spec
+// written by CreateCDISpec.
+func IsTransientSpec(path string) bool {
+	name := filepath.Base(path)
+	for _, class := range []string{cdiClass, cdiPartitionClass} {
+		if strings.HasPrefix(name, cdiapi.GenerateSpecName(cdiVendor, class)+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateCDISpec writes the transient CDI specs of the claim. Whole GPUs and
+// partitions are written to separate specs, one per CDI class.
 func CreateCDISpec(claimUID string, gpus []*GPUDevice) error {
-	specName := cdiapi.GenerateTransientSpecName(cdiVendor, cdiClass, claimUID)
+	var wholeGPUs, partitions []*GPUDevice
+	for _, gpu := range gpus {
+		if gpu.class() == cdiPartitionClass {
+			partitions = append(partitions, gpu)
+			continue
+		}
+		wholeGPUs = append(wholeGPUs, gpu)
+	}
+
+	if len(wholeGPUs) > 0 {
+		if err := createCDISpec(claimUID, cdiClass, cdiKind, wholeGPUs); err != nil {
+			return err
+		}
+	}
+
+	if len(partitions) > 0 {
+		return createCDISpec(claimUID, cdiPartitionClass, cdiPartitionKind, partitions)
+	}
+	return nil
+}
+
+func createCDISpec(claimUID, class, kind string, gpus []*GPUDevice) error {
+	specName := cdiapi.GenerateTransientSpecName(cdiVendor, class, claimUID)
 	spec := &cdispec.Spec{
-		Kind:    cdiKind,
+		Kind:    kind,
 		Devices: []cdispec.Device{},
 	}
 
 	for _, gpu := range gpus {
 		cdiDevice := cdispec.Device{
+			Name:           transientDeviceName(claimUID, gpu),
+			ContainerEdits: baseContainerEdits(class, gpu),
+		}
+		cdiDevice.ContainerEdits.Env = append(cdiDevice.ContainerEdits.Env, deviceEnvVars(gpu)...)
+		spec.Devices = append(spec.Devices, cdiDevice)
+	}
+
+	return writeSpec(spec, specName)
+}
+
+// WriteNodeSpecs writes the node's CDI specs of the GPUs, replacing any
+// previously written ones. Whole GPUs and partitions are written to separate
+// specs, one per CDI class. It's meant for environments without GPUs, where
+// the specs aren't provided by the vendor's tooling.
+func WriteNodeSpecs(gpus []*GPUDevice) error {
+	specs := map[string]*cdispec.Spec{
+		cdiClass:          {Kind: cdiKind, Devices: []cdispec.Device{}},
+		cdiPartitionClass: {Kind: cdiPartitionKind, Devices: []cdispec.Device{}},
+	}
+	for _, gpu := range gpus {
+		spec := specs[gpu.class()]
+		spec.Devices = append(spec.Devices, cdispec.Device{
 			Name: gpu.UUID,
 			ContainerEdits: cdispec.ContainerEdits{
-				Env: []string{
-					fmt.Sprintf("DEVICE_UUID=%s", gpu.UUID),
-					fmt.Sprintf("DEVICE_PRODUCT_NAME=%s", gpu.ProductName),
-					fmt.Sprintf("DEVICE_VENDOR_NAME=%s", gpu.VendorName),
-				},
+				Env: deviceEnvVars(gpu),
 			},
+		})
+	}
+
+	for _, class := range []string{cdiClass, cdiPartitionClass} {
+		specName := cdiapi.GenerateSpecName(cdiVendor, class)
+		if len(specs[class].Devices) == 0 {
+			if err := registry.SpecDB().RemoveSpec(specName); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := writeSpec(specs[class], specName); err != nil {
+			return err
 		}
-		spec.Devices = append(spec.Devices, cdiDevice)
 	}
+	return nil
+}
 
+func writeSpec(spec *cdispec.Spec, specName string) error {
 	minVersion, err := cdiapi.MinimumRequiredVersion(spec)
 	if err != nil {
 		return fmt.Errorf("failed to get minimum required CDI spec version: %v", err)
@@ -88,19 +340,94 @@ func CreateCDISpec(claimUID string, gpus []*GPUDevice) error {
 	return registry.SpecDB().WriteSpec(spec, specName)
 }
 
+// deviceEnvVars returns the env vars that declare the attributes of the GPU to
+// the workloads, and to the discovery of the node's CDI specs.
+func deviceEnvVars(gpu *GPUDevice) []string {
+	env := []string{
+		fmt.Sprintf("%s=%s", envUUID, gpu.UUID),
+		fmt.Sprintf("%s=%s", envProductName, gpu.ProductName),
+		fmt.Sprintf("%s=%s", envVendorName, gpu.VendorName),
+		fmt.Sprintf("%s=%s", envMemory, gpu.Memory.String()),
+	}
+	if gpu.NUMANode != nil {
+		env = append(env, fmt.Sprintf("%s=%d", envNUMANode, *gpu.NUMANode))
+	}
+	if gpu.PCIeRoot != "" {
+		env = append(env, fmt.Sprintf("%s=%s", envPCIeRoot, gpu.PCIeRoot))
+	}
+	if gpu.InterconnectGroup != "" {
+		env = append(env, fmt.Sprintf("%s=%s", envInterconnectGroup, gpu.InterconnectGroup))
+	}
+	if gpu.TimeSliceReplicas > 0 {
+		env = append(env,
+			fmt.Sprintf("%s=%d", envTimeSliceIndex, gpu.TimeSliceIndex),
+			fmt.Sprintf("%s=%d", envTimeSliceReplicas, gpu.TimeSliceReplicas))
+	}
+	if gpu.ParentUUID != "" {
+		env = append(env,
+			fmt.Sprintf("%s=%s", envParentUUID, gpu.ParentUUID),
+			fmt.Sprintf("%s=%s", envPartitionProfile, gpu.PartitionProfile),
+			fmt.Sprintf("%s=%d", envPartitionSlices, gpu.PartitionSlices))
+	}
+	return env
+}
+
+// baseContainerEdits returns the container edits of the device in the node's CDI
+// specs, so that the transient device injects the same device nodes, mounts and
+// hooks. The device's env vars are left out, since the transient device
+// declares its own.
+func baseContainerEdits(class string, gpu *GPUDevice) cdispec.ContainerEdits {
+	device := registry.DeviceDB().GetDevice(cdiapi.QualifiedName(cdiVendor, class, gpu.UUID))
+	if device == nil {
+		return cdispec.ContainerEdits{}
+	}
+
+	return cdispec.ContainerEdits{
+		DeviceNodes: device.ContainerEdits.DeviceNodes,
+		Hooks:       device.ContainerEdits.Hooks,
+		Mounts:      device.ContainerEdits.Mounts,
+	}
+}
+
+// DeleteCDISpec removes the transient CDI specs of the claim, for both whole
+// GPUs and partitions. Specs that don't exist are ignored.
 func DeleteCDISpec(claimUID string) error {
-	specName := cdiapi.GenerateTransientSpecName(cdiVendor, cdiClass, claimUID)
-	return registry.SpecDB().RemoveSpec(specName)
+	var errs []error
+	for _, class := range []string{cdiClass, cdiPartitionClass} {
+		specName := cdiapi.GenerateTransientSpecName(cdiVendor, class, claimUID)
+		if err := registry.SpecDB().RemoveSpec(specName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
+// Specs returns the node's CDI specs of the vendor, and the errors of the spec
+// files found by the last refresh of the registry. Spec files that can't be
+// loaded are reported, since their vendor is unknown. Transient specs are
+// skipped.
 func Specs() ([]*cdiapi.Spec, error) {
-	specs := registry.SpecDB().GetVendorSpecs(cdiVendor)
+	var specs []*cdiapi.Spec
+	for _, spec := range registry.SpecDB().GetVendorSpecs(cdiVendor) {
+		if IsTransientSpec(spec.GetPath()) {
+			continue
+		}
+		specs = append(specs, spec)
+	}
 
-	var errs error
-	for _, spec := range specs {
-		specErrs := registry.SpecDB().GetSpecErrors(spec)
-		specErrs = append(specErrs, errs)
-		errs = errors.Join(specErrs...)
+	var (
+		specErrors = registry.GetErrors()
+		paths      = make([]string, 0, len(specErrors))
+		errs       []error
+	)
+	for path := range specErrors {
+		if !IsTransientSpec(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		errs = append(errs, fmt.Errorf("CDI spec %s: %w", path, errors.Join(specErrors[path]...)))
 	}
-	return specs, errs
+	return specs, errors.Join(errs...)
 }
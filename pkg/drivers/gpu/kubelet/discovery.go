@@ -0,0 +1,153 @@
+package kubelet
+
+import (
+	"fmt"
+	"os"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/kubelet/cdi"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DiscoveryCDI discovers the devices declared by the CDI specs of the node.
+	DiscoveryCDI = "cdi"
+
+	// DiscoverySysfs discovers the display controllers on the PCI bus of the
+	// node, from sysfs.
+	DiscoverySysfs = "sysfs"
+
+	// DiscoveryStatic reads the devices from a static inventory file.
+	DiscoveryStatic = "static"
+)
+
+// Discoverer discovers the devices of the node.
+type Discoverer interface {
+	// Discover returns the devices of the node, in a stable order. If only some
+	// of the devices can't be discovered, it returns the other devices along
+	// with the error. The returned devices are nil if discovery failed.
+	Discover() ([]*gpuv1alpha2.GPUDevice, error)
+}
+
+var (
+	_ Discoverer = &cdiDiscoverer{}
+	_ Discoverer = &sysfsDiscoverer{}
+	_ Discoverer = &staticDiscoverer{}
+)
+
+// cdiDiscoverer discovers the devices declared by the CDI specs in the CDI
+// root. The CDI registry must be initialized before devices are discovered.
+type cdiDiscoverer struct{}
+
+// NewCDIDiscoverer returns a new instance of the CDI discoverer.
+func NewCDIDiscoverer() *cdiDiscoverer {
+	return &cdiDiscoverer{}
+}
+
+// Discover returns the devices declared by the CDI specs. Malformed specs and
+// devices are reported in the error, without failing the discovery of the
+// other devices.
+func (d *cdiDiscoverer) Discover() ([]*gpuv1alpha2.GPUDevice, error) {
+	gpus, err := cdi.DiscoverFromSpecs()
+	if gpus == nil {
+		return nil, err
+	}
+
+	gpuDevices := make([]*gpuv1alpha2.GPUDevice, len(gpus))
+	for i, gpu := range gpus {
+		gpuDevices[i] = &gpuv1alpha2.GPUDevice{
+			UUID:        gpu.UUID,
+			ProductName: gpu.ProductName,
+			Vendor:      gpu.VendorName,
+			Memory:      gpu.Memory,
+			Topology:    gpuTopology(gpu),
+			Partition:   gpuPartition(gpu),
+		}
+	}
+	return gpuDevices, err
+}
+
+// gpuTopology returns the topology of the CDI device, or nil if the device
+// doesn't declare any topology attributes.
+func gpuTopology(gpu *cdi.GPUDevice) *gpuv1alpha2.GPUTopology {
+	if gpu.NUMANode == nil && gpu.PCIeRoot == "" && gpu.InterconnectGroup == "" {
+		return nil
+	}
+
+	return &gpuv1alpha2.GPUTopology{
+		NUMANode:          gpu.NUMANode,
+		PCIeRoot:          gpu.PCIeRoot,
+		InterconnectGroup: gpu.InterconnectGroup,
+	}
+}
+
+// gpuPartition returns the partition of the CDI device, or nil if the device is
+// a whole GPU.
+func gpuPartition(gpu *cdi.GPUDevice) *gpuv1alpha2.GPUPartition {
+	if gpu.ParentUUID == "" {
+		return nil
+	}
+
+	return &gpuv1alpha2.GPUPartition{
+		ParentUUID: gpu.ParentUUID,
+		Profile:    gpu.PartitionProfile,
+		Slices:     gpu.PartitionSlices,
+	}
+}
+
+// staticInventory is the format of the static inventory file, e.g.
+//
+//	gpus:
+//	- uuid: GPU-0
+//	  productName: A100
+//	  vendor: nvidia
+//	  memory: 40Gi
+//	  topology:
+//	    numaNode: 0
+type staticInventory struct {
+	GPUs []gpuv1alpha2.GPUDevice `json:"gpus"`
+}
+
+// staticDiscoverer reads the devices from a static YAML inventory file. The
+// file is re-read on every discovery.
+type staticDiscoverer struct {
+	path string
+}
+
+// NewStaticDiscoverer returns a new instance of the static inventory file
+// discoverer.
+func NewStaticDiscoverer(path string) *staticDiscoverer {
+	return &staticDiscoverer{path: path}
+}
+
+// Discover returns the devices of the inventory file, in the order of the file.
+// Devices must have unique UUIDs.
+func (d *staticDiscoverer) Discover() ([]*gpuv1alpha2.GPUDevice, error) {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory file: %w", err)
+	}
+
+	inventory := &staticInventory{}
+	if err := yaml.UnmarshalStrict(data, inventory); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory file %s: %w", d.path, err)
+	}
+
+	var (
+		gpuDevices = make([]*gpuv1alpha2.GPUDevice, len(inventory.GPUs))
+		uuids      = map[string]bool{}
+	)
+	for i := range inventory.GPUs {
+		gpu := &inventory.GPUs[i]
+		if gpu.UUID == "" {
+			return nil, fmt.Errorf("GPU %d of inventory file %s has no UUID", i, d.path)
+		}
+
+		if uuids[gpu.UUID] {
+			return nil, fmt.Errorf("duplicate GPU %s in inventory file %s", gpu.UUID, d.path)
+		}
+		uuids[gpu.UUID] = true
+		gpuDevices[i] = gpu
+	}
+	return gpuDevices, nil
+}
@@ -0,0 +1,233 @@
+package kubelet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HealthChecker checks the health of the devices of the node.
+type HealthChecker interface {
+	// Check returns nil if the device is healthy, or an error describing why
+	// it isn't.
+	Check(ctx context.Context, gpu *gpuv1alpha2.GPUDevice) error
+}
+
+var (
+	_ HealthChecker = &fileHealthChecker{}
+	_ HealthChecker = &execHealthChecker{}
+)
+
+// fileHealthChecker marks the devices that have a file named after their UUID
+// in its directory as unhealthy, so that a device can be pulled out of
+// rotation by creating the file. The content of the file describes why the
+// device is unhealthy.
+type fileHealthChecker struct {
+	dir string
+}
+
+// NewFileHealthChecker returns a new instance of the file health checker.
+func NewFileHealthChecker(dir string) *fileHealthChecker {
+	return &fileHealthChecker{dir: dir}
+}
+
+// Check returns an error if the file of the device exists.
+func (c *fileHealthChecker) Check(_ context.Context, gpu *gpuv1alpha2.GPUDevice) error {
+	path := filepath.Join(c.dir, gpu.UUID)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read health file %s: %w", path, err)
+	}
+
+	if message := strings.TrimSpace(string(data)); message != "" {
+		return errors.New(message)
+	}
+	return fmt.Errorf("health file %s exists", path)
+}
+
+// execHealthChecker runs a command for every device, with the UUID of the
+// device as its last argument and in the DEVICE_UUID env var. The device is
+// unhealthy if the command fails or times out. The output of the command
+// describes why the device is unhealthy.
+type execHealthChecker struct {
+	command []string
+	timeout time.Duration
+}
+
+// NewExecHealthChecker returns a new instance of the exec health checker.
+func NewExecHealthChecker(command []string, timeout time.Duration) *execHealthChecker {
+	return &execHealthChecker{
+		command: command,
+		timeout: timeout,
+	}
+}
+
+// Check returns an error if the command fails for the device.
+func (c *execHealthChecker) Check(ctx context.Context, gpu *gpuv1alpha2.GPUDevice) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	args := append(append([]string{}, c.command[1:]...), gpu.UUID)
+	cmd := exec.CommandContext(ctx, c.command[0], args...)
+	cmd.Env = append(os.Environ(), "DEVICE_UUID="+gpu.UUID)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(output.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+// MonitorHealth runs the health checkers against the devices of the inventory
+// every period, and records the results in the status of the NodeGPUSlices
+// object. Devices that fail any checker are unhealthy. The status is only
+// written when the health of a device changes. It blocks until the context is
+// cancelled.
+func (n *NodeServer) MonitorHealth(ctx context.Context, checkers []HealthChecker, period time.Duration) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	n.log.Info().Msgf("monitoring device health every %s with %d checkers", period, len(checkers))
+	var last map[string]string
+	for {
+		unhealthy := n.checkHealth(ctx, checkers)
+		if last == nil || !equalHealth(last, unhealthy) {
+			if err := n.updateHealth(ctx, unhealthy); err != nil {
+				n.log.Error().Err(err).Msg("failed to update device health")
+			} else {
+				last = unhealthy
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHealth runs the health checkers against the devices of the inventory.
+// It returns the UUIDs of the unhealthy devices, mapped to why they're
+// unhealthy.
+func (n *NodeServer) checkHealth(ctx context.Context, checkers []HealthChecker) map[string]string {
+	gpuDevices, _ := n.inventory()
+	unhealthy := map[string]string{}
+	for _, gpu := range gpuDevices {
+		var messages []string
+		for _, checker := range checkers {
+			if err := checker.Check(ctx, gpu); err != nil {
+				messages = append(messages, err.Error())
+			}
+		}
+
+		if len(messages) > 0 {
+			n.log.Warn().Str("deviceUUID", gpu.UUID).Strs("reasons", messages).Msg("device is unhealthy")
+			unhealthy[gpu.UUID] = strings.Join(messages, "; ")
+		}
+	}
+	return unhealthy
+}
+
+// updateHealth records the health of the devices of the inventory in the
+// status of the NodeGPUSlices object, and updates its Healthy condition. The
+// transition time of a device is only updated when its status changes. The
+// NodeListAndWatchResources streams are notified, so that the unhealthy
+// devices are withdrawn.
+func (n *NodeServer) updateHealth(ctx context.Context, unhealthy map[string]string) error {
+	gpuDevices, _ := n.inventory()
+	err := n.updateNodeDevices(ctx, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+		var (
+			now          = metav1.Now()
+			deviceHealth = map[string]gpuv1alpha2.DeviceHealth{}
+		)
+		for _, gpu := range gpuDevices {
+			health := gpuv1alpha2.DeviceHealth{Status: gpuv1alpha2.DeviceHealthStatusHealthy}
+			if message, found := unhealthy[gpu.UUID]; found {
+				health.Status = gpuv1alpha2.DeviceHealthStatusUnhealthy
+				health.Message = message
+			}
+
+			health.LastTransitionTime = now
+			if current, found := nodeDevices.Status.DeviceHealth[gpu.UUID]; found && current.Status == health.Status {
+				health.LastTransitionTime = current.LastTransitionTime
+			}
+			deviceHealth[gpu.UUID] = health
+		}
+		nodeDevices.Status.DeviceHealth = deviceHealth
+
+		if len(unhealthy) == 0 {
+			meta.SetStatusCondition(&nodeDevices.Status.Conditions, metav1.Condition{
+				Type:               gpuv1alpha2.NodeGPUSlicesConditionHealthy,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: nodeDevices.GetGeneration(),
+				Reason:             "HealthChecksPassed",
+				Message:            "all the devices passed their health checks",
+			})
+			return nil
+		}
+
+		uuids := make([]string, 0, len(unhealthy))
+		for uuid := range unhealthy {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+
+		meta.SetStatusCondition(&nodeDevices.Status.Conditions, metav1.Condition{
+			Type:               gpuv1alpha2.NodeGPUSlicesConditionHealthy,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: nodeDevices.GetGeneration(),
+			Reason:             "DevicesUnhealthy",
+			Message:            fmt.Sprintf("devices failed their health checks: %s", strings.Join(uuids, ", ")),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.unhealthy = unhealthy
+	n.notifyInventoryChanged()
+	return nil
+}
+
+// isHealthy returns true if the device passed its latest health checks.
+func (n *NodeServer) isHealthy(uuid string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, found := n.unhealthy[uuid]
+	return !found
+}
+
+func equalHealth(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for uuid, message := range a {
+		if other, found := b[uuid]; !found || other != message {
+			return false
+		}
+	}
+	return true
+}
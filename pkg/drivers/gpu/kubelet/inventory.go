@@ -0,0 +1,205 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/kubelet/cdi"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileDelay is how long the CDI root must be quiet before the inventory is
+// reconciled, since spec files are often written in several steps.
+const reconcileDelay = time.Second
+
+// WatchCDIRoot watches the CDI root directory, and reconciles the device
+// inventory of the node whenever its CDI specs change. Changes to the transient
+// specs of the claims are ignored. It blocks until the context is cancelled.
+func (n *NodeServer) WatchCDIRoot(ctx context.Context, cdiRoot string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create CDI root watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(cdiRoot); err != nil {
+		return fmt.Errorf("failed to watch CDI root %s: %w", cdiRoot, err)
+	}
+	n.log.Info().Str("cdiRoot", cdiRoot).Msg("watching CDI root for changes")
+
+	var reconcile <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if ext := filepath.Ext(event.Name); (ext != ".json" && ext != ".yaml") || cdi.IsTransientSpec(event.Name) {
+				continue
+			}
+			n.log.Debug().Str("file", event.Name).Str("op", event.Op.String()).Msg("CDI spec changed")
+			reconcile = time.After(reconcileDelay)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			n.log.Error().Err(err).Msg("CDI root watcher error")
+
+		case <-reconcile:
+			reconcile = nil
+			if err := n.reconcileInventory(ctx); err != nil {
+				n.log.Error().Err(err).Msg("failed to reconcile device inventory")
+			}
+		}
+	}
+}
+
+// updateInventory applies the device inventory to the spec of the
+// NodeGPUSlices object, and notifies the NodeListAndWatchResources streams of
+// the new inventory.
+func (n *NodeServer) updateInventory(ctx context.Context, gpuDevices []*gpuv1alpha2.GPUDevice) error {
+	if err := n.applySpec(ctx, gpuDevices); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.gpuDevices = gpuDevices
+	n.notifyInventoryChanged()
+	return nil
+}
+
+// notifyInventoryChanged wakes up the NodeListAndWatchResources streams. The
+// caller must hold the lock.
+func (n *NodeServer) notifyInventoryChanged() {
+	close(n.inventoryChanged)
+	n.inventoryChanged = make(chan struct{})
+}
+
+// inventory returns the current device inventory, and a channel that is closed
+// when the inventory changes.
+func (n *NodeServer) inventory() ([]*gpuv1alpha2.GPUDevice, <-chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.gpuDevices, n.inventoryChanged
+}
+
+// reconcileInventory rediscovers the devices of the node, and updates
+// the device inventory of the node if it changed.
+func (n *NodeServer) reconcileInventory(ctx context.Context) error {
+	gpuDevices, err := n.discoverer.Discover()
+	if gpuDevices == nil {
+		return err
+	}
+	if err != nil {
+		n.log.Error().Err(err).Msg("failed to discover some devices")
+	}
+
+	current, _ := n.inventory()
+	if apiequality.Semantic.DeepEqual(current, gpuDevices) {
+		return nil
+	}
+
+	n.log.Info().Msgf("reconciling device inventory: %d devices (was %d)", len(gpuDevices), len(current))
+	if err := n.updateInventory(ctx, gpuDevices); err != nil {
+		return err
+	}
+	return n.updateInventoryConditions(ctx, gpuDevices)
+}
+
+// updateInventoryConditions updates the InventoryReady and DevicesMissing
+// conditions of the NodeGPUSlices object. Devices that vanish while they're
+// allocated or prepared are dropped from the inventory like any other device,
+// but they're flagged by the DevicesMissing condition. The conditions are
+// re-evaluated whenever the inventory is reconciled.
+func (n *NodeServer) updateInventoryConditions(ctx context.Context, gpuDevices []*gpuv1alpha2.GPUDevice) error {
+	return n.updateNodeDevices(ctx, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+		meta.SetStatusCondition(&nodeDevices.Status.Conditions, metav1.Condition{
+			Type:               gpuv1alpha2.NodeGPUSlicesConditionInventoryReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: nodeDevices.GetGeneration(),
+			Reason:             "DevicesDiscovered",
+			Message:            fmt.Sprintf("discovered %d devices", len(gpuDevices)),
+		})
+
+		missing := missingDevices(nodeDevices, gpuDevices)
+		if len(missing) == 0 {
+			meta.SetStatusCondition(&nodeDevices.Status.Conditions, metav1.Condition{
+				Type:               gpuv1alpha2.NodeGPUSlicesConditionDevicesMissing,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: nodeDevices.GetGeneration(),
+				Reason:             "AllocatedDevicesDiscovered",
+				Message:            "all the allocated devices are discovered",
+			})
+			return nil
+		}
+
+		details := make([]string, 0, len(missing))
+		for uuid, claimUIDs := range missing {
+			details = append(details, fmt.Sprintf("%s (claims %s)", uuid, strings.Join(claimUIDs, ", ")))
+			n.log.Warn().Str("deviceUUID", uuid).Strs("claims", claimUIDs).Msg("allocated device vanished from the node")
+		}
+		sort.Strings(details)
+
+		meta.SetStatusCondition(&nodeDevices.Status.Conditions, metav1.Condition{
+			Type:               gpuv1alpha2.NodeGPUSlicesConditionDevicesMissing,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: nodeDevices.GetGeneration(),
+			Reason:             "AllocatedDevicesVanished",
+			Message:            fmt.Sprintf("allocated devices vanished from the node: %s", strings.Join(details, "; ")),
+		})
+		return nil
+	})
+}
+
+// missingDevices returns the UUIDs of the allocated or prepared devices that
+// aren't in the inventory, mapped to the UIDs of the claims they're allocated
+// to.
+func missingDevices(nodeDevices *gpuv1alpha2.NodeGPUSlices, gpuDevices []*gpuv1alpha2.GPUDevice) map[string][]string {
+	inventory := map[string]bool{}
+	for _, gpu := range gpuDevices {
+		inventory[gpu.UUID] = true
+	}
+
+	missing := map[string][]string{}
+	for claimUID, allocations := range nodeDevices.Status.Allocations {
+		for _, allocation := range allocations {
+			if allocation.State != gpuv1alpha2.DeviceAllocationStateAllocated && allocation.State != gpuv1alpha2.DeviceAllocationStatePrepared {
+				continue
+			}
+
+			if uuid := allocation.Device.UUID; !inventory[uuid] {
+				missing[uuid] = append(missing[uuid], claimUID)
+			}
+		}
+	}
+
+	for _, claimUIDs := range missing {
+		sort.Strings(claimUIDs)
+	}
+	return missing
+}
+
+// hasDevice returns true if the device is in the inventory of the node.
+func (n *NodeServer) hasDevice(uuid string) bool {
+	gpuDevices, _ := n.inventory()
+	for _, gpu := range gpuDevices {
+		if gpu.UUID == uuid {
+			return true
+		}
+	}
+	return false
+}
@@ -2,19 +2,45 @@ package kubelet
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/ihcsim/k8s-dra/pkg/apis"
+	gpuapplyv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/applyconfiguration/gpu/v1alpha2"
 	draclientset "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned"
 	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
 	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/kubelet/cdi"
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/migration"
 	zlog "github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
 	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
-	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	coreclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
 	kubeletdrav1 "k8s.io/kubelet/pkg/apis/dra/v1alpha3"
 )
 
-const AvailableGPUsCount = 4
+const (
+	AvailableGPUsCount = 4
+
+	// fieldManagerPrefix is the prefix of the server-side apply field manager of
+	// the NodeGPUSlices fields owned by the kubelet plugin. The node name is
+	// appended to it, so that each plugin instance has its own field manager.
+	// The plugin is the source of truth for the devices of its node, so it
+	// forces its applies to take over the allocations that it prepares from the
+	// controller.
+	fieldManagerPrefix = "dra-plugin-"
+
+	// timeSliceInfix separates the UUID of a GPU from the index of its
+	// time-sliced replica in the names of the named resources instances.
+	timeSliceInfix = "-slice-"
+)
 
 var _ kubeletdrav1.NodeServer = &NodeServer{}
 
@@ -25,58 +51,115 @@ type NodeServer struct {
 	log        zlog.Logger
 	namespace  string
 	nodeName   string
+
+	// nodeUID is the UID of the Node that owns the NodeGPUSlices object.
+	nodeUID types.UID
+
+	// discoverer discovers the devices of the node.
+	discoverer Discoverer
+
+	// timeSliceReplicas is the number of time-sliced replicas advertised for
+	// each GPU, or 0 if whole GPUs are advertised.
+	timeSliceReplicas int
+
+	// gpuDevices is the device inventory of the node, and unhealthy maps the
+	// UUIDs of the devices that failed their latest health checks to why they
+	// failed. The inventoryChanged channel is closed and replaced whenever the
+	// inventory or the health of its devices changes, to wake up the
+	// NodeListAndWatchResources streams.
+	mu               sync.Mutex
+	gpuDevices       []*gpuv1alpha2.GPUDevice
+	unhealthy        map[string]string
+	inventoryChanged chan struct{}
 }
 
-// NewNodeServer returns a new instance of the NodeServer. It also initializes
-// the associated	NodeGPUSlices object, which defines the device specs of the
-// corresponding node.
+// NewNodeServer returns a new instance of the NodeServer. It also applies the
+// devices found by the discoverer to the spec of the associated NodeGPUSlices
+// object, creating it if it doesn't exist. The object is owned by the Node, so
+// that it's garbage-collected with the Node. The CDI root is where the CDI
+// specs of the prepared claims are written. If timeSliceReplicas is greater
+// than 1, each GPU is advertised as that many time-sliced replicas.
 func NewNodeServer(
 	ctx context.Context,
+	coreClientSets coreclientset.Interface,
 	clientSets draclientset.Interface,
+	discoverer Discoverer,
+	timeSliceReplicas int,
 	cdiRoot string,
 	namespace string,
 	nodeName string,
 	log zlog.Logger) (*NodeServer, error) {
 	logger := log.With().Str("namespace", namespace).Logger()
-	logger.Info().Msg("initializing CDI registry and discovering CDI devices...")
-	cdi.InitRegistryOnce(cdiRoot)
-	gpus, err := cdi.DiscoverFromSpecs()
+	node, err := coreClientSets.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
 	}
-	logger.Info().Msgf("discovered %d CDI devices", len(gpus))
 
-	gpuDevices := make([]*gpuv1alpha1.GPUDevice, len(gpus))
-	for i, gpu := range gpus {
-		gpuDevices[i] = &gpuv1alpha1.GPUDevice{
-			UUID:        gpu.UUID,
-			ProductName: gpu.ProductName,
-			Vendor:      gpu.VendorName,
-		}
+	logger.Info().Msg("initializing CDI registry and discovering devices...")
+	cdi.InitRegistryOnce(cdiRoot)
+	gpuDevices, err := discoverer.Discover()
+	if gpuDevices == nil {
+		return nil, err
 	}
-
-	if _, err := clientSets.GpuV1alpha1().NodeGPUSlices(namespace).Get(ctx, nodeName, metav1.GetOptions{}); err != nil && apierrs.IsNotFound(err) {
-		nodeDevices := &gpuv1alpha1.NodeGPUSlices{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      nodeName,
-				Namespace: namespace,
-			},
-			AllocatableGPUs: gpuDevices,
-			Allocations:     map[string][]*gpuv1alpha1.DeviceAllocation{},
-			NodeSuitability: map[string]gpuv1alpha1.NodeSuitability{},
-		}
-		logger.Info().Msgf("creating new NodeGPUSlices %s...", nodeName)
-		if _, err := clientSets.GpuV1alpha1().NodeGPUSlices(namespace).Create(ctx, nodeDevices, metav1.CreateOptions{}); err != nil {
-			return nil, err
-		}
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to discover some devices")
 	}
+	logger.Info().Msgf("discovered %d devices", len(gpuDevices))
 
-	return &NodeServer{
+	n := &NodeServer{
 		clientSets: clientSets,
 		log:        logger,
 		namespace:  namespace,
 		nodeName:   nodeName,
-	}, nil
+		nodeUID:    node.GetUID(),
+		discoverer: discoverer,
+
+		timeSliceReplicas: timeSliceReplicas,
+
+		inventoryChanged: make(chan struct{}),
+	}
+
+	// the spec is applied through the v1alpha2 API, which drops the allocations
+	// of an object stored in v1alpha1, so the object is migrated first
+	if err := migration.MigrateNodeGPUSlice(ctx, clientSets, namespace, nodeName, logger); err != nil {
+		return nil, err
+	}
+
+	logger.Info().Msgf("applying NodeGPUSlices %s...", nodeName)
+	if err := n.updateInventory(ctx, gpuDevices); err != nil {
+		return nil, err
+	}
+
+	if err := n.updateInventoryConditions(ctx, gpuDevices); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// applySpec applies the device inventory of the node to the spec of the
+// NodeGPUSlices object, creating the object if it doesn't exist. The plugin is
+// the only writer of the spec, and of the owner reference to the Node.
+func (n *NodeServer) applySpec(ctx context.Context, gpuDevices []*gpuv1alpha2.GPUDevice) error {
+	spec := gpuapplyv1alpha2.NodeGPUSlicesSpec()
+	for i := range gpuDevices {
+		spec.WithAllocatableGPUs(&gpuDevices[i])
+	}
+
+	owner := metav1ac.OwnerReference().
+		WithAPIVersion("v1").
+		WithKind("Node").
+		WithName(n.nodeName).
+		WithUID(n.nodeUID)
+	applyConfig := gpuapplyv1alpha2.NodeGPUSlices(n.nodeName, n.namespace).
+		WithOwnerReferences(owner).
+		WithSpec(spec)
+	applyOpts := metav1.ApplyOptions{
+		FieldManager: fieldManagerPrefix + n.nodeName,
+		Force:        true,
+	}
+	_, err := n.clientSets.GpuV1alpha2().NodeGPUSlices(n.namespace).Apply(ctx, applyConfig, applyOpts)
+	return err
 }
 
 // NodePrepareResources prepares several ResourceClaims for use on the node.
@@ -87,72 +170,107 @@ func (n *NodeServer) NodePrepareResources(ctx context.Context, req *kubeletdrav1
 		Claims: map[string]*kubeletdrav1.NodePrepareResourceResponse{},
 	}
 
-	nodeDevices, err := n.clientSets.GpuV1alpha1().NodeGPUSlices(n.namespace).Get(ctx, n.nodeName, metav1.GetOptions{})
+	nodeDevices, err := n.clientSets.GpuV1alpha2().NodeGPUSlices(n.namespace).Get(ctx, n.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	for _, claim := range req.Claims {
 		claimUID := claim.GetUid()
+		if len(claim.GetStructuredResourceHandle()) > 0 {
+			res.Claims[claimUID] = n.nodePrepareStructuredResource(ctx, nodeDevices, claim)
+			continue
+		}
 		res.Claims[claimUID] = n.nodePrepareResource(ctx, nodeDevices, claimUID)
 	}
 
 	return res, nil
 }
 
-func (n *NodeServer) nodePrepareResource(ctx context.Context, nodeDevices *gpuv1alpha1.NodeGPUSlices, claimUID string) *kubeletdrav1.NodePrepareResourceResponse {
+func (n *NodeServer) nodePrepareResource(ctx context.Context, nodeDevices *gpuv1alpha2.NodeGPUSlices, claimUID string) *kubeletdrav1.NodePrepareResourceResponse {
 	var (
-		cdiDevices = []*cdi.GPUDevice{}
-		res        = &kubeletdrav1.NodePrepareResourceResponse{}
-		log        = n.log.With().Str("claim", claimUID).Logger()
+		cdiDevices      = []*cdi.GPUDevice{}
+		res             = &kubeletdrav1.NodePrepareResourceResponse{}
+		log             = n.log.With().Str("claim", claimUID).Logger()
+		needsTransition = false
 	)
 
-	claimAllocations, exists := nodeDevices.Allocations[claimUID]
+	claimAllocations, exists := nodeDevices.Status.Allocations[claimUID]
 	if !exists {
 		log.Info().Msg("no device allocation found")
 		return &kubeletdrav1.NodePrepareResourceResponse{}
 	}
 
-	for i, claimAllocation := range claimAllocations {
-		if claimAllocation.State != gpuv1alpha1.DeviceAllocationStateAllocated && claimAllocation.State != gpuv1alpha1.DeviceAllocationStatePrepared {
+	for _, claimAllocation := range claimAllocations {
+		if claimAllocation.State != gpuv1alpha2.DeviceAllocationStateAllocated && claimAllocation.State != gpuv1alpha2.DeviceAllocationStatePrepared {
 			log.Info().Msg("device allocation is not in either allocated or protected state")
 			return &kubeletdrav1.NodePrepareResourceResponse{}
 		}
 
-		var (
-			device    = claimAllocation.Device
-			cdiDevice = &cdi.GPUDevice{
-				UUID:        device.UUID,
-				ProductName: device.ProductName,
-				VendorName:  device.Vendor,
-			}
-			qualifiedName = cdi.DeviceQualifiedName(cdiDevice)
-		)
+		device := claimAllocation.Device
+		if !n.hasDevice(device.UUID) {
+			log.Error().Str("deviceUUID", device.UUID).Msg("allocated device is missing from the node")
+			res.Error = fmt.Sprintf("device %s is missing from the node", device.UUID)
+			return res
+		}
+
+		cdiDevice := &cdi.GPUDevice{
+			UUID:        device.UUID,
+			ProductName: device.ProductName,
+			VendorName:  device.Vendor,
+			Memory:      device.Memory,
+		}
+		if partition := device.Partition; partition != nil {
+			cdiDevice.ParentUUID = partition.ParentUUID
+			cdiDevice.PartitionProfile = partition.Profile
+			cdiDevice.PartitionSlices = partition.Slices
+		}
+		qualifiedName := cdi.DeviceQualifiedName(claimUID, cdiDevice)
+
+		// fractional allocations only give the claim part of the device's memory
+		if claimAllocation.Memory != nil {
+			cdiDevice.Memory = *claimAllocation.Memory
+		}
+		if topology := device.Topology; topology != nil {
+			cdiDevice.NUMANode = topology.NUMANode
+			cdiDevice.PCIeRoot = topology.PCIeRoot
+			cdiDevice.InterconnectGroup = topology.InterconnectGroup
+		}
+		if timeSlice := claimAllocation.TimeSlice; timeSlice != nil {
+			cdiDevice.TimeSliceIndex = timeSlice.Index
+			cdiDevice.TimeSliceReplicas = timeSlice.Replicas
+		}
 
 		log.Info().
 			Str("deviceUUID", device.UUID).
 			Str("deviceProductName", device.ProductName).
 			Str("deviceVendor", device.Vendor).
+			Str("deviceMemory", cdiDevice.Memory.String()).
 			Str("deviceState", string(claimAllocation.State)).
 			Str("qualifiedName", qualifiedName).
 			Msg("preparing CDI device...")
 		res.CDIDevices = append(res.CDIDevices, qualifiedName)
 		cdiDevices = append(cdiDevices, cdiDevice)
 
-		if claimAllocation.State == gpuv1alpha1.DeviceAllocationStateAllocated {
-			claimAllocationClone := claimAllocation.DeepCopy()
-			claimAllocationClone.State = gpuv1alpha1.DeviceAllocationStatePrepared
-
-			nodeDevicesClone := nodeDevices.DeepCopy()
-			nodeDevicesClone.Allocations[claimUID][i] = claimAllocationClone
+		if claimAllocation.State == gpuv1alpha2.DeviceAllocationStateAllocated {
+			needsTransition = true
+		}
+	}
 
-			if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				_, err := n.clientSets.GpuV1alpha1().NodeGPUSlices(n.namespace).Update(ctx, nodeDevicesClone, metav1.UpdateOptions{})
-				return err
-			}); err != nil {
-				res.Error = err.Error()
-				return res
+	// the prepared state of all the claim's devices is applied at once, which
+	// transfers the ownership of the claim's allocation from the controller to
+	// the plugin
+	if needsTransition {
+		if err := n.updateNodeDevices(ctx, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+			for _, claimAllocation := range nodeDevices.Status.Allocations[claimUID] {
+				if claimAllocation.State == gpuv1alpha2.DeviceAllocationStateAllocated {
+					claimAllocation.State = gpuv1alpha2.DeviceAllocationStatePrepared
+				}
 			}
+			return nil
+		}); err != nil {
+			res.Error = err.Error()
+			return res
 		}
 	}
 
@@ -165,6 +283,105 @@ func (n *NodeServer) nodePrepareResource(ctx context.Context, nodeDevices *gpuv1
 	return res
 }
 
+// nodePrepareStructuredResource prepares a claim allocated by the scheduler
+// with structured parameters. The controller doesn't know about the devices
+// allocated to the claim, so the plugin records them as prepared allocations in
+// the NodeGPUSlices object, before preparing them like the other claims. The
+// allocations keep the controller from allocating the devices to other claims,
+// and are removed when the claim is unprepared.
+func (n *NodeServer) nodePrepareStructuredResource(ctx context.Context, nodeDevices *gpuv1alpha2.NodeGPUSlices, claim *kubeletdrav1.Claim) *kubeletdrav1.NodePrepareResourceResponse {
+	claimUID := claim.GetUid()
+	if _, exists := nodeDevices.Status.Allocations[claimUID]; exists {
+		return n.nodePrepareResource(ctx, nodeDevices, claimUID)
+	}
+
+	allocations, err := n.structuredAllocations(claim)
+	if err != nil {
+		n.log.Error().Err(err).Str("claim", claimUID).Msg("failed to map structured resource handle to devices")
+		return &kubeletdrav1.NodePrepareResourceResponse{Error: err.Error()}
+	}
+
+	if err := n.updateNodeDevices(ctx, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+		if nodeDevices.Status.Allocations == nil {
+			nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{}
+		}
+		nodeDevices.Status.Allocations[claimUID] = allocations
+		return nil
+	}); err != nil {
+		return &kubeletdrav1.NodePrepareResourceResponse{Error: err.Error()}
+	}
+
+	if nodeDevices.Status.Allocations == nil {
+		nodeDevices.Status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{}
+	}
+	nodeDevices.Status.Allocations[claimUID] = allocations
+	return n.nodePrepareResource(ctx, nodeDevices, claimUID)
+}
+
+// structuredAllocations returns the prepared allocations of the named resources
+// instances in the structured resource handles of the claim. The instance names
+// are mapped back to the devices of the inventory, and to their time-sliced
+// replicas.
+func (n *NodeServer) structuredAllocations(claim *kubeletdrav1.Claim) ([]*gpuv1alpha2.DeviceAllocation, error) {
+	type instance struct {
+		gpu       *gpuv1alpha2.GPUDevice
+		timeSlice *gpuv1alpha2.TimeSlice
+	}
+
+	gpuDevices, _ := n.inventory()
+	instances := map[string]instance{}
+	for _, gpu := range gpuDevices {
+		instances[instanceName(gpu)] = instance{gpu: gpu}
+		if n.timeSliceReplicas <= 1 {
+			continue
+		}
+
+		for i := 0; i < n.timeSliceReplicas; i++ {
+			instances[timeSliceInstanceName(gpu, i)] = instance{
+				gpu:       gpu,
+				timeSlice: &gpuv1alpha2.TimeSlice{Index: i, Replicas: n.timeSliceReplicas},
+			}
+		}
+	}
+
+	// the claim is referenced the same way as in the allocations of the
+	// controller
+	apiGroup := apis.GroupName
+	claimRef := corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     gpuv1alpha1.GPURequirementsKind,
+		Name:     claim.GetUid(),
+	}
+	allocations := []*gpuv1alpha2.DeviceAllocation{}
+	for _, handle := range claim.GetStructuredResourceHandle() {
+		if handle.NodeName != "" && handle.NodeName != n.nodeName {
+			return nil, fmt.Errorf("claim is allocated on node %s", handle.NodeName)
+		}
+
+		for _, result := range handle.Results {
+			if result.NamedResources == nil {
+				continue
+			}
+
+			instance, found := instances[result.NamedResources.Name]
+			if !found {
+				return nil, fmt.Errorf("named resources instance %s isn't found on the node", result.NamedResources.Name)
+			}
+			allocations = append(allocations, &gpuv1alpha2.DeviceAllocation{
+				Claim:     claimRef,
+				Device:    instance.gpu.DeepCopy(),
+				State:     gpuv1alpha2.DeviceAllocationStatePrepared,
+				TimeSlice: instance.timeSlice,
+			})
+		}
+	}
+
+	if len(allocations) == 0 {
+		return nil, fmt.Errorf("no named resources instances are allocated to the claim")
+	}
+	return allocations, nil
+}
+
 // NodeUnprepareResources is the opposite of NodePrepareResources.
 // see https://pkg.go.dev/k8s.io/kubelet/pkg/apis/dra/v1alpha3#NodeServer
 func (n *NodeServer) NodeUnprepareResources(ctx context.Context, req *kubeletdrav1.NodeUnprepareResourcesRequest) (*kubeletdrav1.NodeUnprepareResourcesResponse, error) {
@@ -182,29 +399,28 @@ func (n *NodeServer) NodeUnprepareResources(ctx context.Context, req *kubeletdra
 }
 
 func (n *NodeServer) nodeUnprepareResource(ctx context.Context, claimUID string) *kubeletdrav1.NodeUnprepareResourceResponse {
-	nodeDevices, err := n.clientSets.GpuV1alpha1().NodeGPUSlices(n.namespace).Get(ctx, n.nodeName, metav1.GetOptions{})
+	nodeDevices, err := n.clientSets.GpuV1alpha2().NodeGPUSlices(n.namespace).Get(ctx, n.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return &kubeletdrav1.NodeUnprepareResourceResponse{
 			Error: err.Error(),
 		}
 	}
 
-	if _, exists := nodeDevices.Allocations[claimUID]; !exists {
+	if _, exists := nodeDevices.Status.Allocations[claimUID]; !exists {
 		n.log.Info().Msg("no device allocation found, skipping resource unpreparation...")
 		return &kubeletdrav1.NodeUnprepareResourceResponse{}
 	}
 
 	n.log.Info().Str("claimUID", claimUID).Msg("unpreparing claim allocations...")
-	delete(nodeDevices.Allocations, claimUID)
 	if err := cdi.DeleteCDISpec(claimUID); err != nil {
 		return &kubeletdrav1.NodeUnprepareResourceResponse{
 			Error: err.Error(),
 		}
 	}
 
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_, err := n.clientSets.GpuV1alpha1().NodeGPUSlices(n.namespace).Update(ctx, nodeDevices, metav1.UpdateOptions{})
-		return err
+	if err := n.updateNodeDevices(ctx, func(nodeDevices *gpuv1alpha2.NodeGPUSlices) error {
+		delete(nodeDevices.Status.Allocations, claimUID)
+		return nil
 	}); err != nil {
 		return &kubeletdrav1.NodeUnprepareResourceResponse{
 			Error: err.Error(),
@@ -214,19 +430,224 @@ func (n *NodeServer) nodeUnprepareResource(ctx context.Context, claimUID string)
 	return &kubeletdrav1.NodeUnprepareResourceResponse{}
 }
 
+// updateNodeDevices reads the NodeGPUSlices of the node, applies the mutation to
+// it and writes the plugin-owned status fields back to the API server with
+// server-side apply. On conflicts, the object is re-read and the mutation is
+// re-applied.
+func (n *NodeServer) updateNodeDevices(ctx context.Context, mutate func(*gpuv1alpha2.NodeGPUSlices) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodeDevices, err := n.clientSets.GpuV1alpha2().NodeGPUSlices(n.namespace).Get(ctx, n.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(nodeDevices); err != nil {
+			return err
+		}
+		applyOpts := metav1.ApplyOptions{
+			FieldManager: fieldManagerPrefix + n.nodeName,
+			Force:        true,
+		}
+		_, err = n.clientSets.GpuV1alpha2().NodeGPUSlices(n.namespace).ApplyStatus(ctx, n.applyConfiguration(nodeDevices), applyOpts)
+		return err
+	})
+}
+
+// applyConfiguration returns the apply configuration of the NodeGPUSlices status
+// fields owned by the plugin, i.e. the prepared allocations, the device health,
+// the allocatable count and the conditions. The allocated count is left to the
+// controller. Owned fields that are left out of the configuration are removed
+// by the API server.
+func (n *NodeServer) applyConfiguration(nodeDevices *gpuv1alpha2.NodeGPUSlices) *gpuapplyv1alpha2.NodeGPUSlicesApplyConfiguration {
+	allocations := map[string][]*gpuv1alpha2.DeviceAllocation{}
+	for claimUID, claimAllocations := range nodeDevices.Status.Allocations {
+		if !nodeDevices.Status.IsPrepared(claimUID) {
+			continue
+		}
+		allocations[claimUID] = claimAllocations
+	}
+
+	deviceHealth := map[string]gpuapplyv1alpha2.DeviceHealthApplyConfiguration{}
+	for uuid, health := range nodeDevices.Status.DeviceHealth {
+		deviceHealth[uuid] = *gpuapplyv1alpha2.DeviceHealth().
+			WithStatus(health.Status).
+			WithMessage(health.Message).
+			WithLastTransitionTime(health.LastTransitionTime)
+	}
+
+	status := gpuapplyv1alpha2.NodeGPUSlicesStatus().
+		WithAllocations(allocations).
+		WithDeviceHealth(deviceHealth).
+		WithAllocatableCount(len(nodeDevices.Spec.AllocatableGPUs))
+	for _, condition := range nodeDevices.Status.Conditions {
+		status.WithConditions(metav1ac.Condition().
+			WithType(condition.Type).
+			WithStatus(condition.Status).
+			WithObservedGeneration(condition.ObservedGeneration).
+			WithLastTransitionTime(condition.LastTransitionTime).
+			WithReason(condition.Reason).
+			WithMessage(condition.Message))
+	}
+
+	return gpuapplyv1alpha2.NodeGPUSlices(n.nodeName, n.namespace).
+		WithResourceVersion(nodeDevices.GetResourceVersion()).
+		WithStatus(status)
+}
+
 // NodeListAndWatchResources returns a stream of NodeResourcesResponse objects.
 // see https://pkg.go.dev/k8s.io/kubelet/pkg/apis/dra/v1alpha3#NodeServer
+// There is one named resources instance per discovered healthy GPU, or per
+// time-sliced replica of the GPU if the GPUs are time-sliced. The stream
+// is kept open until its context is cancelled, and a new response is sent
+// whenever the inventory or the health of its devices changes. Identical
+// consecutive responses are skipped.
 func (n *NodeServer) NodeListAndWatchResources(req *kubeletdrav1.NodeListAndWatchResourcesRequest, s kubeletdrav1.Node_NodeListAndWatchResourcesServer) error {
+	var last *kubeletdrav1.NodeListAndWatchResourcesResponse
+	for {
+		inventory, changed := n.inventory()
+		gpuDevices := make([]*gpuv1alpha2.GPUDevice, 0, len(inventory))
+		for _, gpu := range inventory {
+			if n.isHealthy(gpu.UUID) {
+				gpuDevices = append(gpuDevices, gpu)
+			}
+		}
+
+		res := n.nodeResources(gpuDevices)
+		if last == nil || !apiequality.Semantic.DeepEqual(res, last) {
+			n.log.Info().Msgf("publishing %d named resources instances", len(res.Resources[0].NamedResources.Instances))
+			if err := s.Send(res); err != nil {
+				return err
+			}
+			last = res
+		}
+
+		select {
+		case <-s.Context().Done():
+			n.log.Info().Msg("closing named resources stream")
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// nodeResources returns the NodeListAndWatchResources response of the device
+// inventory. If the GPUs are time-sliced, each GPU has one instance per
+// time-sliced replica. The devices whose instance names aren't DNS labels are
+// logged and left out, so that they don't fail the whole response.
+func (n *NodeServer) nodeResources(gpuDevices []*gpuv1alpha2.GPUDevice) *kubeletdrav1.NodeListAndWatchResourcesResponse {
 	namedResources := &resourcev1alpha2.NamedResourcesResources{
-		Instances: []resourcev1alpha2.NamedResourcesInstance{
-			{Name: "test-named-resource-instance"},
+		Instances: make([]resourcev1alpha2.NamedResourcesInstance, 0, len(gpuDevices)),
+	}
+	for _, gpu := range gpuDevices {
+		instances := []resourcev1alpha2.NamedResourcesInstance{namedResourcesInstance(gpu)}
+		if n.timeSliceReplicas > 1 {
+			instances = make([]resourcev1alpha2.NamedResourcesInstance, 0, n.timeSliceReplicas)
+			for i := 0; i < n.timeSliceReplicas; i++ {
+				instances = append(instances, timeSliceInstance(gpu, i, n.timeSliceReplicas))
+			}
+		}
+
+		if err := validateInstanceNames(instances); err != nil {
+			n.log.Error().Err(err).Str("deviceUUID", gpu.UUID).Msg("skipping device with invalid named resources instance")
+			continue
+		}
+		namedResources.Instances = append(namedResources.Instances, instances...)
+	}
+
+	return &kubeletdrav1.NodeListAndWatchResourcesResponse{
+		Resources: []*resourcev1alpha2.ResourceModel{
+			{NamedResources: namedResources},
+		},
+	}
+}
+
+// namedResourcesInstance returns the named resources instance of the GPU. The
+// instance is named after the lowercased UUID of the GPU, since instance names
+// must be DNS labels. The topology and partition attributes are only set if
+// they are known.
+func namedResourcesInstance(gpu *gpuv1alpha2.GPUDevice) resourcev1alpha2.NamedResourcesInstance {
+	memory := gpu.Memory.DeepCopy()
+	attributes := []resourcev1alpha2.NamedResourcesAttribute{
+		stringAttribute("uuid", gpu.UUID),
+		stringAttribute("productName", gpu.ProductName),
+		stringAttribute("vendor", gpu.Vendor),
+		{
+			Name:                         "memory",
+			NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{QuantityValue: &memory},
 		},
 	}
-	resources := []*resourcev1alpha2.ResourceModel{
-		{NamedResources: namedResources},
+
+	if topology := gpu.Topology; topology != nil {
+		if topology.NUMANode != nil {
+			attributes = append(attributes, intAttribute("numaNode", *topology.NUMANode))
+		}
+		if topology.PCIeRoot != "" {
+			attributes = append(attributes, stringAttribute("pcieRoot", topology.PCIeRoot))
+		}
+		if topology.InterconnectGroup != "" {
+			attributes = append(attributes, stringAttribute("interconnectGroup", topology.InterconnectGroup))
+		}
+	}
+
+	if partition := gpu.Partition; partition != nil {
+		attributes = append(attributes,
+			stringAttribute("parentUUID", partition.ParentUUID),
+			stringAttribute("partitionProfile", partition.Profile),
+			intAttribute("partitionSlices", partition.Slices))
 	}
-	res := &kubeletdrav1.NodeListAndWatchResourcesResponse{
-		Resources: resources,
+
+	return resourcev1alpha2.NamedResourcesInstance{
+		Name:       instanceName(gpu),
+		Attributes: attributes,
+	}
+}
+
+// validateInstanceNames returns an error if any of the instance names isn't a
+// DNS label.
+func validateInstanceNames(instances []resourcev1alpha2.NamedResourcesInstance) error {
+	for _, instance := range instances {
+		if errs := validation.IsDNS1123Label(instance.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid instance name %q: %s", instance.Name, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// timeSliceInstance returns the named resources instance of the time-sliced
+// replica of the GPU at the index. It has the attributes of the GPU, along with
+// the index and the number of replicas. It's named after the GPU instance and
+// the index.
+func timeSliceInstance(gpu *gpuv1alpha2.GPUDevice, index, replicas int) resourcev1alpha2.NamedResourcesInstance {
+	instance := namedResourcesInstance(gpu)
+	instance.Name = timeSliceInstanceName(gpu, index)
+	instance.Attributes = append(instance.Attributes,
+		intAttribute("timeSliceIndex", index),
+		intAttribute("timeSliceReplicas", replicas))
+	return instance
+}
+
+// instanceName returns the name of the named resources instance of the GPU.
+func instanceName(gpu *gpuv1alpha2.GPUDevice) string {
+	return strings.ToLower(gpu.UUID)
+}
+
+// timeSliceInstanceName returns the name of the named resources instance of
+// the time-sliced replica of the GPU at the index.
+func timeSliceInstanceName(gpu *gpuv1alpha2.GPUDevice, index int) string {
+	return fmt.Sprintf("%s%s%d", instanceName(gpu), timeSliceInfix, index)
+}
+
+func stringAttribute(name, value string) resourcev1alpha2.NamedResourcesAttribute {
+	return resourcev1alpha2.NamedResourcesAttribute{
+		Name:                         name,
+		NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{StringValue: &value},
+	}
+}
+
+func intAttribute(name string, value int) resourcev1alpha2.NamedResourcesAttribute {
+	v := int64(value)
+	return resourcev1alpha2.NamedResourcesAttribute{
+		Name:                         name,
+		NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{IntValue: &v},
 	}
-	return s.Send(res)
 }
@@ -0,0 +1,87 @@
+package kubelet
+
+import (
+	"testing"
+
+	"github.com/ihcsim/k8s-dra/pkg/apis"
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	zlog "github.com/rs/zerolog"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	kubeletdrav1 "k8s.io/kubelet/pkg/apis/dra/v1alpha3"
+)
+
+func structuredClaim(nodeName string, instanceNames ...string) *kubeletdrav1.Claim {
+	handle := &resourcev1alpha2.StructuredResourceHandle{NodeName: nodeName}
+	for _, name := range instanceNames {
+		handle.Results = append(handle.Results, resourcev1alpha2.DriverAllocationResult{
+			AllocationResultModel: resourcev1alpha2.AllocationResultModel{
+				NamedResources: &resourcev1alpha2.NamedResourcesAllocationResult{Name: name},
+			},
+		})
+	}
+
+	return &kubeletdrav1.Claim{
+		Namespace:                "default",
+		Uid:                      "claim-uid",
+		Name:                     "claim",
+		StructuredResourceHandle: []*resourcev1alpha2.StructuredResourceHandle{handle},
+	}
+}
+
+func TestStructuredAllocations(t *testing.T) {
+	n := &NodeServer{
+		log:               zlog.Nop(),
+		nodeName:          "node-0",
+		timeSliceReplicas: 2,
+		gpuDevices: []*gpuv1alpha2.GPUDevice{
+			{UUID: "GPU-A", ProductName: "A100-SXM4-40GB", Vendor: "nvidia"},
+			{UUID: "GPU-B", ProductName: "A100-SXM4-40GB", Vendor: "nvidia"},
+		},
+	}
+
+	allocations, err := n.structuredAllocations(structuredClaim("node-0", "gpu-a", "gpu-b-slice-1"))
+	if err != nil {
+		t.Fatalf("failed to map the structured resource handles: %v", err)
+	}
+	if len(allocations) != 2 {
+		t.Fatalf("expected 2 allocations, got %d", len(allocations))
+	}
+
+	for _, allocation := range allocations {
+		// the claim must be referenced the same way as by the controller
+		claim := allocation.Claim
+		if claim.APIGroup == nil || *claim.APIGroup != apis.GroupName || claim.Kind != gpuv1alpha1.GPURequirementsKind || claim.Name != "claim-uid" {
+			t.Errorf("unexpected claim reference %+v", claim)
+		}
+		if allocation.State != gpuv1alpha2.DeviceAllocationStatePrepared {
+			t.Errorf("expected prepared allocation, got %s", allocation.State)
+		}
+	}
+
+	if whole := allocations[0]; whole.Device.UUID != "GPU-A" || whole.TimeSlice != nil {
+		t.Errorf("expected whole GPU-A, got %s with time slice %+v", whole.Device.UUID, whole.TimeSlice)
+	}
+	if slice := allocations[1]; slice.Device.UUID != "GPU-B" || slice.TimeSlice == nil || slice.TimeSlice.Index != 1 || slice.TimeSlice.Replicas != 2 {
+		t.Errorf("expected time-sliced replica 1 of GPU-B, got %s with time slice %+v", slice.Device.UUID, slice.TimeSlice)
+	}
+}
+
+func TestStructuredAllocationsErrors(t *testing.T) {
+	n := &NodeServer{
+		log:        zlog.Nop(),
+		nodeName:   "node-0",
+		gpuDevices: []*gpuv1alpha2.GPUDevice{{UUID: "GPU-A"}},
+	}
+
+	testCases := map[string]*kubeletdrav1.Claim{
+		"other node":       structuredClaim("node-1", "gpu-a"),
+		"unknown instance": structuredClaim("node-0", "gpu-b"),
+		"time slicing off": structuredClaim("node-0", "gpu-a-slice-0"),
+	}
+	for name, claim := range testCases {
+		if _, err := n.structuredAllocations(claim); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
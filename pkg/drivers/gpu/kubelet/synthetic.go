@@ -0,0 +1,101 @@
+package kubelet
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/ihcsim/k8s-dra/pkg/drivers/gpu/kubelet/cdi"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// SyntheticGPUs describes the synthetic GPUs generated for environments
+// without GPUs.
+type SyntheticGPUs struct {
+	// Count is the number of GPUs. It defaults to AvailableGPUsCount.
+	Count int
+
+	// Products is the mix of GPU products, as vendor/product pairs, e.g.
+	// "nvidia/A100". The GPUs are assigned products in a round-robin fashion.
+	Products []string
+
+	// Memory is the mix of memory sizes, e.g. "40Gi". The GPUs are assigned
+	// memory sizes in a round-robin fashion.
+	Memory []string
+
+	// NUMANodes is the number of NUMA nodes the GPUs are spread across, in
+	// contiguous blocks. Each NUMA node has its own PCIe root. The GPUs have
+	// no NUMA topology if it's 0.
+	NUMANodes int
+
+	// InterconnectGroupSize is the number of consecutive GPUs in each
+	// interconnect group. The GPUs have no interconnect groups if it's 0.
+	InterconnectGroupSize int
+}
+
+// GenerateSyntheticGPUs writes the CDI specs of the synthetic GPUs of the node
+// into the CDI root. The GPU UUIDs are derived from the node name, so that the
+// inventory is stable across restarts.
+func GenerateSyntheticGPUs(cdiRoot, nodeName string, synthetic SyntheticGPUs) ([]*cdi.GPUDevice, error) {
+	count := synthetic.Count
+	if count <= 0 {
+		count = AvailableGPUsCount
+	}
+
+	if len(synthetic.Products) == 0 || len(synthetic.Memory) == 0 {
+		return nil, fmt.Errorf("synthetic GPUs need at least one product and one memory size")
+	}
+
+	if synthetic.NUMANodes < 0 || synthetic.InterconnectGroupSize < 0 {
+		return nil, fmt.Errorf("synthetic GPUs need non-negative NUMA nodes and interconnect group size")
+	}
+
+	memory := make([]resource.Quantity, len(synthetic.Memory))
+	for i, value := range synthetic.Memory {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid synthetic GPU memory %q: %w", value, err)
+		}
+		memory[i] = quantity
+	}
+
+	gpus := make([]*cdi.GPUDevice, count)
+	for i := range gpus {
+		vendor, product, found := strings.Cut(synthetic.Products[i%len(synthetic.Products)], "/")
+		if !found || vendor == "" || product == "" {
+			return nil, fmt.Errorf("invalid synthetic GPU product %q, must be vendor/product", synthetic.Products[i%len(synthetic.Products)])
+		}
+
+		gpu := &cdi.GPUDevice{
+			UUID:        syntheticUUID(nodeName, i),
+			ProductName: product,
+			VendorName:  vendor,
+			Memory:      memory[i%len(memory)],
+		}
+
+		if synthetic.NUMANodes > 0 {
+			numaNode := i * synthetic.NUMANodes / count
+			gpu.NUMANode = &numaNode
+			gpu.PCIeRoot = fmt.Sprintf("pci0000:%02x", numaNode)
+		}
+
+		if synthetic.InterconnectGroupSize > 0 {
+			gpu.InterconnectGroup = fmt.Sprintf("group-%d", i/synthetic.InterconnectGroupSize)
+		}
+
+		gpus[i] = gpu
+	}
+
+	cdi.InitRegistryOnce(cdiRoot)
+	if err := cdi.WriteNodeSpecs(gpus); err != nil {
+		return nil, fmt.Errorf("failed to write synthetic GPU CDI specs: %w", err)
+	}
+	return gpus, nil
+}
+
+// syntheticUUID returns a GPU UUID derived from the node name and the index of
+// the GPU.
+func syntheticUUID(nodeName string, index int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s/%d", nodeName, index)))
+	return fmt.Sprintf("GPU-%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
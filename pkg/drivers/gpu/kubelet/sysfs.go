@@ -0,0 +1,156 @@
+package kubelet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// pciClassDisplay is the PCI base class of display controllers, which includes
+// VGA and 3D controllers.
+const pciClassDisplay = "0x03"
+
+// pciVendors maps the PCI vendor IDs of the common GPU vendors to their names.
+// The IDs of other vendors are used as their names.
+var pciVendors = map[string]string{
+	"0x10de": "nvidia",
+	"0x1002": "amd",
+	"0x8086": "intel",
+}
+
+// sysfsDiscoverer discovers the display controllers on the PCI bus, from the
+// bus/pci/devices directory of a sysfs tree. The root of the tree is
+// configurable, so that the discoverer can be run against a fake tree.
+type sysfsDiscoverer struct {
+	root string
+}
+
+// NewSysfsDiscoverer returns a new instance of the sysfs discoverer. The root
+// is usually /sys.
+func NewSysfsDiscoverer(root string) *sysfsDiscoverer {
+	return &sysfsDiscoverer{root: root}
+}
+
+// Discover returns the display controllers on the PCI bus, ordered by their PCI
+// address. The devices are named after their PCI address, and their products
+// are their PCI vendor and device IDs, in the lspci format. Their memory is
+// only known if the driver reports it in mem_info_vram_total, and their NUMA
+// node is only known if the kernel reports it. Devices whose attributes can't
+// be read are skipped, and returned in the error along with the other devices.
+func (d *sysfsDiscoverer) Discover() ([]*gpuv1alpha2.GPUDevice, error) {
+	devicesDir := filepath.Join(d.root, "bus", "pci", "devices")
+	entries, err := os.ReadDir(devicesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PCI devices: %w", err)
+	}
+
+	var (
+		gpuDevices = []*gpuv1alpha2.GPUDevice{}
+		errs       error
+	)
+	for _, entry := range entries {
+		gpu, err := discoverPCIDevice(filepath.Join(devicesDir, entry.Name()))
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if gpu != nil {
+			gpuDevices = append(gpuDevices, gpu)
+		}
+	}
+
+	return gpuDevices, errs
+}
+
+// discoverPCIDevice returns the GPU of the PCI device directory, or nil if the
+// device isn't a display controller.
+func discoverPCIDevice(dir string) (*gpuv1alpha2.GPUDevice, error) {
+	address := filepath.Base(dir)
+	class, err := readSysfsAttribute(dir, "class")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(class, pciClassDisplay) {
+		return nil, nil
+	}
+
+	vendorID, err := readSysfsAttribute(dir, "vendor")
+	if err != nil {
+		return nil, err
+	}
+
+	deviceID, err := readSysfsAttribute(dir, "device")
+	if err != nil {
+		return nil, err
+	}
+
+	vendor, known := pciVendors[vendorID]
+	if !known {
+		vendor = strings.TrimPrefix(vendorID, "0x")
+	}
+
+	gpu := &gpuv1alpha2.GPUDevice{
+		UUID:        "PCI-" + strings.NewReplacer(":", "-", ".", "-").Replace(address),
+		ProductName: strings.TrimPrefix(vendorID, "0x") + ":" + strings.TrimPrefix(deviceID, "0x"),
+		Vendor:      vendor,
+	}
+
+	if value, err := readSysfsAttribute(dir, "mem_info_vram_total"); err == nil {
+		bytes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory %q of PCI device %s", value, address)
+		}
+		gpu.Memory = *resource.NewQuantity(bytes, resource.BinarySI)
+	}
+
+	topology := &gpuv1alpha2.GPUTopology{
+		PCIeRoot: pcieRoot(dir),
+	}
+	if value, err := readSysfsAttribute(dir, "numa_node"); err == nil {
+		// the kernel reports -1 if the NUMA node is unknown
+		if numaNode, err := strconv.Atoi(value); err == nil && numaNode >= 0 {
+			topology.NUMANode = &numaNode
+		}
+	}
+	if topology.NUMANode != nil || topology.PCIeRoot != "" {
+		gpu.Topology = topology
+	}
+
+	return gpu, nil
+}
+
+// readSysfsAttribute returns the trimmed content of the attribute file of the
+// device.
+func readSysfsAttribute(dir, attribute string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, attribute))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s of PCI device %s: %w", attribute, filepath.Base(dir), err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// pcieRoot returns the PCIe root complex of the device, e.g. pci0000:00. The
+// devices under bus/pci/devices are symlinks into the devices tree, where the
+// first element of their path under devices is their root complex. It returns
+// an empty string if the device isn't linked to a root complex, as in fake
+// trees without symlinks.
+func pcieRoot(dir string) string {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return ""
+	}
+
+	elements := strings.Split(filepath.ToSlash(resolved), "/")
+	for i := 1; i < len(elements); i++ {
+		if elements[i-1] == "devices" && strings.HasPrefix(elements[i], "pci") {
+			return elements[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package kubelet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePCIDevice creates the directory of a PCI device under the devices tree of
+// the fake sysfs root, linked from bus/pci/devices, with the attribute files.
+func fakePCIDevice(t *testing.T, root, pcieRoot, address string, attributes map[string]string) {
+	t.Helper()
+
+	dir := filepath.Join(root, "devices", pcieRoot, address)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range attributes {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	if err := os.MkdirAll(devicesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(devicesDir, address)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSysfsDiscover(t *testing.T) {
+	root := t.TempDir()
+	fakePCIDevice(t, root, "pci0000:00", "0000:01:00.0", map[string]string{
+		"class":               "0x030000",
+		"vendor":              "0x1002",
+		"device":              "0x73bf",
+		"mem_info_vram_total": "17163091968",
+		"numa_node":           "1",
+	})
+	fakePCIDevice(t, root, "pci0000:80", "0000:81:00.0", map[string]string{
+		"class":     "0x030200",
+		"vendor":    "0x10de",
+		"device":    "0x20b0",
+		"numa_node": "-1",
+	})
+
+	// network controllers aren't GPUs
+	fakePCIDevice(t, root, "pci0000:00", "0000:02:00.0", map[string]string{
+		"class":  "0x020000",
+		"vendor": "0x8086",
+		"device": "0x1533",
+	})
+
+	// a display controller without a device ID can't be discovered
+	fakePCIDevice(t, root, "pci0000:00", "0000:03:00.0", map[string]string{
+		"class":  "0x030000",
+		"vendor": "0x8086",
+	})
+
+	gpuDevices, err := NewSysfsDiscoverer(root).Discover()
+	if err == nil {
+		t.Error("expected an error for the device without a device ID")
+	}
+	if len(gpuDevices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(gpuDevices))
+	}
+
+	amd := gpuDevices[0]
+	if amd.UUID != "PCI-0000-01-00-0" {
+		t.Errorf("unexpected UUID %s", amd.UUID)
+	}
+	if amd.ProductName != "1002:73bf" || amd.Vendor != "amd" {
+		t.Errorf("unexpected product %s of vendor %s", amd.ProductName, amd.Vendor)
+	}
+	if amd.Memory.Value() != 17163091968 {
+		t.Errorf("unexpected memory %s", amd.Memory.String())
+	}
+	if amd.Topology == nil || amd.Topology.PCIeRoot != "pci0000:00" || amd.Topology.NUMANode == nil || *amd.Topology.NUMANode != 1 {
+		t.Errorf("unexpected topology %+v", amd.Topology)
+	}
+
+	nvidia := gpuDevices[1]
+	if nvidia.UUID != "PCI-0000-81-00-0" || nvidia.Vendor != "nvidia" {
+		t.Errorf("unexpected UUID %s of vendor %s", nvidia.UUID, nvidia.Vendor)
+	}
+	if !nvidia.Memory.IsZero() {
+		t.Errorf("expected unknown memory, got %s", nvidia.Memory.String())
+	}
+	if nvidia.Topology == nil || nvidia.Topology.PCIeRoot != "pci0000:80" || nvidia.Topology.NUMANode != nil {
+		t.Errorf("unexpected topology %+v", nvidia.Topology)
+	}
+}
+
+func TestSysfsDiscoverWithoutPCIDevices(t *testing.T) {
+	gpuDevices, err := NewSysfsDiscoverer(t.TempDir()).Discover()
+	if err == nil {
+		t.Error("expected an error without a PCI devices directory")
+	}
+	if gpuDevices != nil {
+		t.Errorf("expected no devices, got %d", len(gpuDevices))
+	}
+}
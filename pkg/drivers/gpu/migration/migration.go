@@ -0,0 +1,138 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	draclientset "github.com/ihcsim/k8s-dra/pkg/apis/clientset/versioned"
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+	zlog "github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MigrateNodeGPUSlices migrates all the NodeGPUSlices of the namespace that are
+// stored in v1alpha1 to v1alpha2. See MigrateNodeGPUSlice.
+func MigrateNodeGPUSlices(ctx context.Context, clientsets draclientset.Interface, namespace string, log zlog.Logger) error {
+	list, err := clientsets.GpuV1alpha1().NodeGPUSlices(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list v1alpha1 NodeGPUSlices: %w", err)
+	}
+
+	var errs error
+	for i := range list.Items {
+		if err := migrate(ctx, clientsets, &list.Items[i], log); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// MigrateNodeGPUSlice migrates the NodeGPUSlices of the node to v1alpha2, if
+// it's stored in v1alpha1. The CRD has no conversion webhook, so the objects
+// stored in v1alpha1 are only readable through the v1alpha1 API. Their
+// v1alpha1 fields are pruned when they're read or written through the v1alpha2
+// API. The object is rewritten through the v1alpha2 API, with its allocations
+// in the status and its inventory in the spec. The status is written first,
+// since the inventory is rediscovered by the kubelet plugin, but the
+// allocations aren't.
+func MigrateNodeGPUSlice(ctx context.Context, clientsets draclientset.Interface, namespace, nodeName string, log zlog.Logger) error {
+	nodeDevices, err := clientsets.GpuV1alpha1().NodeGPUSlices(namespace).Get(ctx, nodeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get v1alpha1 NodeGPUSlices %s: %w", nodeName, err)
+	}
+
+	return migrate(ctx, clientsets, nodeDevices, log)
+}
+
+// migrate rewrites the object through the v1alpha2 API. Objects stored in
+// v1alpha2 have no v1alpha1 fields when they're read through the v1alpha1 API,
+// so they're skipped.
+func migrate(ctx context.Context, clientsets draclientset.Interface, old *gpuv1alpha1.NodeGPUSlices, log zlog.Logger) error {
+	if len(old.AllocatableGPUs) == 0 && len(old.Allocations) == 0 && len(old.NodeSuitability) == 0 {
+		return nil
+	}
+
+	var (
+		name   = old.GetName()
+		client = clientsets.GpuV1alpha2().NodeGPUSlices(old.GetNamespace())
+	)
+	log.Info().Str("node", name).Msg("migrating NodeGPUSlices from v1alpha1 to v1alpha2...")
+
+	nodeDevices, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get NodeGPUSlices %s: %w", name, err)
+	}
+
+	nodeDevices.Status = convertStatus(old)
+	nodeDevices, err = client.UpdateStatus(ctx, nodeDevices, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to migrate status of NodeGPUSlices %s: %w", name, err)
+	}
+
+	nodeDevices.Spec.AllocatableGPUs = convertDevices(old.AllocatableGPUs)
+	if _, err := client.Update(ctx, nodeDevices, metav1.UpdateOptions{}); err != nil {
+		// the kubelet plugin restores the inventory when it applies the spec
+		log.Warn().Err(err).Str("node", name).Msg("failed to migrate spec of NodeGPUSlices")
+	}
+
+	log.Info().Str("node", name).Msg("migrated NodeGPUSlices to v1alpha2")
+	return nil
+}
+
+// convertStatus returns the v1alpha2 status of the allocation state of the
+// v1alpha1 object.
+func convertStatus(old *gpuv1alpha1.NodeGPUSlices) gpuv1alpha2.NodeGPUSlicesStatus {
+	status := gpuv1alpha2.NodeGPUSlicesStatus{
+		AllocatableCount: len(old.AllocatableGPUs),
+	}
+
+	if len(old.Allocations) > 0 {
+		status.Allocations = map[string][]*gpuv1alpha2.DeviceAllocation{}
+	}
+	for claimUID, allocations := range old.Allocations {
+		for _, allocation := range allocations {
+			status.Allocations[claimUID] = append(status.Allocations[claimUID], &gpuv1alpha2.DeviceAllocation{
+				Claim:         allocation.Claim,
+				Device:        convertDevice(allocation.Device),
+				State:         gpuv1alpha2.DeviceAllocationState(allocation.State),
+				HoldTimestamp: allocation.HoldTimestamp,
+			})
+		}
+	}
+
+	if len(old.NodeSuitability) > 0 {
+		status.NodeSuitability = map[string]gpuv1alpha2.NodeSuitability{}
+	}
+	for claimUID, suitability := range old.NodeSuitability {
+		status.NodeSuitability[claimUID] = gpuv1alpha2.NodeSuitability(suitability)
+	}
+
+	return status
+}
+
+func convertDevices(old []*gpuv1alpha1.GPUDevice) []*gpuv1alpha2.GPUDevice {
+	gpuDevices := make([]*gpuv1alpha2.GPUDevice, 0, len(old))
+	for _, gpu := range old {
+		gpuDevices = append(gpuDevices, convertDevice(gpu))
+	}
+	return gpuDevices
+}
+
+func convertDevice(old *gpuv1alpha1.GPUDevice) *gpuv1alpha2.GPUDevice {
+	if old == nil {
+		return nil
+	}
+
+	return &gpuv1alpha2.GPUDevice{
+		UUID:        old.UUID,
+		ProductName: old.ProductName,
+		Vendor:      old.Vendor,
+		Memory:      old.Memory.DeepCopy(),
+	}
+}
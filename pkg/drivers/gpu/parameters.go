@@ -0,0 +1,681 @@
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuinformers "github.com/ihcsim/k8s-dra/pkg/apis/informers/externalversions/gpu/v1alpha1"
+	gpulistersv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/listers/gpu/v1alpha1"
+	zlog "github.com/rs/zerolog"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	resourceinformers "k8s.io/client-go/informers/resource/v1alpha2"
+	coreclientset "k8s.io/client-go/kubernetes"
+	resourcelisters "k8s.io/client-go/listers/resource/v1alpha2"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// errUntranslatable is returned when GPU parameters can't be expressed as
+// structured parameters.
+var errUntranslatable = errors.New("parameters can't be expressed as structured parameters")
+
+// parametersKey identifies the GPURequirements or GPUClassParameters object to
+// generate structured parameters for.
+type parametersKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// parametersController generates the ResourceClaimParameters and
+// ResourceClassParameters of the GPURequirements and GPUClassParameters
+// objects, so that the scheduler can allocate GPUs from the named resources
+// published by the kubelet plugins, without the round trips of the classic DRA
+// controller.
+// ResourceClaimParameters are generated in the namespace of their
+// GPURequirements, and ResourceClassParameters in the namespace of the driver.
+// Generated objects are owned by their source objects, so they are garbage
+// collected when their sources are deleted.
+type parametersController struct {
+	coreClientSets            coreclientset.Interface
+	claimParamsLister         gpulistersv1alpha1.GPURequirementsLister
+	classParamsLister         gpulistersv1alpha1.GPUClassParametersLister
+	resourceClaimParamsLister resourcelisters.ResourceClaimParametersLister
+	resourceClassParamsLister resourcelisters.ResourceClassParametersLister
+	resourceClaimLister       resourcelisters.ResourceClaimLister
+	resourceClassLister       resourcelisters.ResourceClassLister
+	expressions               *expressionEvaluator
+	queue                     workqueue.RateLimitingInterface
+	cacheSynced               []cache.InformerSynced
+	namespace                 string
+	log                       zlog.Logger
+}
+
+// NewParametersController returns a new instance of the structured parameters
+// controller. The informers must be started by the caller.
+func NewParametersController(
+	coreClientSets coreclientset.Interface,
+	claimParams gpuinformers.GPURequirementsInformer,
+	classParams gpuinformers.GPUClassParametersInformer,
+	resourceClaimParams resourceinformers.ResourceClaimParametersInformer,
+	resourceClassParams resourceinformers.ResourceClassParametersInformer,
+	resourceClaims resourceinformers.ResourceClaimInformer,
+	resourceClasses resourceinformers.ResourceClassInformer,
+	namespace string,
+	log zlog.Logger) (*parametersController, error) {
+	expressions, err := newExpressionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &parametersController{
+		coreClientSets:            coreClientSets,
+		claimParamsLister:         claimParams.Lister(),
+		classParamsLister:         classParams.Lister(),
+		resourceClaimParamsLister: resourceClaimParams.Lister(),
+		resourceClassParamsLister: resourceClassParams.Lister(),
+		resourceClaimLister:       resourceClaims.Lister(),
+		resourceClassLister:       resourceClasses.Lister(),
+		expressions:               expressions,
+		queue: workqueue.NewRateLimitingQueueWithConfig(
+			workqueue.DefaultControllerRateLimiter(),
+			workqueue.RateLimitingQueueConfig{Name: "structured-parameters"}),
+		namespace: namespace,
+		log:       log,
+	}
+
+	sourceHandler := func(kind string) cache.ResourceEventHandlerFuncs {
+		enqueue := func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if meta, ok := obj.(metav1.Object); ok {
+				c.queue.Add(parametersKey{kind: kind, namespace: meta.GetNamespace(), name: meta.GetName()})
+			}
+		}
+		return cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueue,
+			UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+			DeleteFunc: enqueue,
+		}
+	}
+
+	// changes to the generated objects are reverted by re-syncing their sources
+	generatedHandler := func(source func(obj interface{}) (parametersKey, bool)) cache.ResourceEventHandlerFuncs {
+		enqueue := func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if key, ok := source(obj); ok {
+				c.queue.Add(key)
+			}
+		}
+		return cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+			DeleteFunc: enqueue,
+		}
+	}
+
+	// the claims and classes that reference GPURequirements determine whether
+	// the claims are shareable
+	claimHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if key, ok := claimParametersKey(obj); ok {
+				c.queue.Add(key)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if key, ok := claimParametersKey(obj); ok {
+				c.queue.Add(key)
+			}
+		},
+	}
+	classHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.enqueueAllClaimParameters() },
+		UpdateFunc: func(_, _ interface{}) { c.enqueueAllClaimParameters() },
+		DeleteFunc: func(interface{}) { c.enqueueAllClaimParameters() },
+	}
+
+	handlers := []struct {
+		informer cache.SharedIndexInformer
+		handler  cache.ResourceEventHandler
+	}{
+		{claimParams.Informer(), sourceHandler(gpuv1alpha1.GPURequirementsKind)},
+		{classParams.Informer(), sourceHandler(gpuv1alpha1.GPUClassParametersKind)},
+		{classParams.Informer(), classHandler},
+		{resourceClasses.Informer(), classHandler},
+		{resourceClaims.Informer(), claimHandler},
+		{resourceClaimParams.Informer(), generatedHandler(func(obj interface{}) (parametersKey, bool) {
+			generated, ok := obj.(*resourcev1alpha2.ResourceClaimParameters)
+			if !ok || generated.GeneratedFrom == nil ||
+				generated.GeneratedFrom.APIGroup != apiGroup ||
+				generated.GeneratedFrom.Kind != gpuv1alpha1.GPURequirementsKind {
+				return parametersKey{}, false
+			}
+			return parametersKey{
+				kind:      gpuv1alpha1.GPURequirementsKind,
+				namespace: generated.GetNamespace(),
+				name:      generated.GeneratedFrom.Name,
+			}, true
+		})},
+		{resourceClassParams.Informer(), generatedHandler(func(obj interface{}) (parametersKey, bool) {
+			generated, ok := obj.(*resourcev1alpha2.ResourceClassParameters)
+			if !ok || generated.GeneratedFrom == nil ||
+				generated.GeneratedFrom.APIGroup != apiGroup ||
+				generated.GeneratedFrom.Kind != gpuv1alpha1.GPUClassParametersKind {
+				return parametersKey{}, false
+			}
+			return parametersKey{
+				kind: gpuv1alpha1.GPUClassParametersKind,
+				name: generated.GeneratedFrom.Name,
+			}, true
+		})},
+	}
+	for _, h := range handlers {
+		if _, err := h.informer.AddEventHandler(h.handler); err != nil {
+			return nil, err
+		}
+		c.cacheSynced = append(c.cacheSynced, h.informer.HasSynced)
+	}
+
+	return c, nil
+}
+
+// claimParametersKey returns the key of the GPURequirements referenced by the
+// claim, if any.
+func claimParametersKey(obj interface{}) (parametersKey, bool) {
+	claim, ok := obj.(*resourcev1alpha2.ResourceClaim)
+	if !ok {
+		return parametersKey{}, false
+	}
+
+	ref := claim.Spec.ParametersRef
+	if ref == nil || ref.APIGroup != apiGroup || ref.Kind != gpuv1alpha1.GPURequirementsKind {
+		return parametersKey{}, false
+	}
+	return parametersKey{
+		kind:      gpuv1alpha1.GPURequirementsKind,
+		namespace: claim.GetNamespace(),
+		name:      ref.Name,
+	}, true
+}
+
+// enqueueAllClaimParameters enqueues all the GPURequirements, since any of them
+// can be referenced by the claims of a changed class.
+func (c *parametersController) enqueueAllClaimParameters() {
+	claimParams, err := c.claimParamsLister.List(labels.Everything())
+	if err != nil {
+		c.log.Error().Err(err).Msg("failed to list GPURequirements")
+		return
+	}
+
+	for _, p := range claimParams {
+		c.queue.Add(parametersKey{kind: gpuv1alpha1.GPURequirementsKind, namespace: p.GetNamespace(), name: p.GetName()})
+	}
+}
+
+// Run starts the workers of the controller, once the informer caches are
+// synced. It blocks until the context is cancelled.
+func (c *parametersController) Run(ctx context.Context, workers int) {
+	defer c.queue.ShutDown()
+
+	c.log.Info().Msg("waiting for structured parameters caches to sync")
+	if !cache.WaitForCacheSync(ctx.Done(), c.cacheSynced...) {
+		c.log.Error().Msg("failed to sync structured parameters caches")
+		return
+	}
+
+	c.log.Info().Int("workers", workers).Msg("starting structured parameters controller")
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+
+	<-ctx.Done()
+	c.log.Info().Msg("stopping structured parameters controller")
+}
+
+func (c *parametersController) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *parametersController) processNextItem(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	key := item.(parametersKey)
+	log := c.log.With().
+		Str("kind", key.kind).
+		Str("namespace", key.namespace).
+		Str("name", key.name).
+		Logger()
+
+	var err error
+	switch key.kind {
+	case gpuv1alpha1.GPURequirementsKind:
+		err = c.syncClaimParameters(ctx, key.namespace, key.name)
+	case gpuv1alpha1.GPUClassParametersKind:
+		err = c.syncClassParameters(ctx, key.name)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sync structured parameters, requeueing")
+		c.queue.AddRateLimited(item)
+		return true
+	}
+
+	c.queue.Forget(item)
+	return true
+}
+
+// syncClaimParameters creates or updates the ResourceClaimParameters generated
+// from the GPURequirements. If the GPURequirements can't be translated, any
+// previously generated ResourceClaimParameters is deleted, so that the
+// scheduler doesn't allocate devices for stale requirements.
+func (c *parametersController) syncClaimParameters(ctx context.Context, namespace, name string) error {
+	claimParams, err := c.claimParamsLister.GPURequirements(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// the generated object is garbage collected with its owner
+			return nil
+		}
+		return err
+	}
+
+	existing, err := c.resourceClaimParamsLister.ResourceClaimParameters(namespace).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if existing != nil && !metav1.IsControlledBy(existing, claimParams) {
+		return fmt.Errorf("ResourceClaimParameters %s/%s isn't generated from GPURequirements %s", namespace, name, name)
+	}
+
+	desired, err := c.resourceClaimParameters(claimParams)
+	if err != nil {
+		c.log.Warn().Err(err).Msgf("skipping GPURequirements %s/%s", namespace, name)
+		if existing == nil {
+			return nil
+		}
+		return c.coreClientSets.ResourceV1alpha2().ResourceClaimParameters(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+
+	client := c.coreClientSets.ResourceV1alpha2().ResourceClaimParameters(namespace)
+	if existing == nil {
+		c.log.Info().Msgf("creating ResourceClaimParameters %s/%s", namespace, name)
+		_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+
+	if apiequality.Semantic.DeepEqual(existing.GeneratedFrom, desired.GeneratedFrom) &&
+		existing.Shareable == desired.Shareable &&
+		apiequality.Semantic.DeepEqual(existing.DriverRequests, desired.DriverRequests) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.GeneratedFrom = desired.GeneratedFrom
+	updated.Shareable = desired.Shareable
+	updated.DriverRequests = desired.DriverRequests
+	c.log.Info().Msgf("updating ResourceClaimParameters %s/%s", namespace, name)
+	_, err = client.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// syncClassParameters creates or updates the ResourceClassParameters generated
+// from the GPUClassParameters. If the GPUClassParameters can't be translated,
+// any previously generated ResourceClassParameters is deleted.
+func (c *parametersController) syncClassParameters(ctx context.Context, name string) error {
+	classParams, err := c.classParamsLister.Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	existing, err := c.resourceClassParamsLister.ResourceClassParameters(c.namespace).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if existing != nil && !metav1.IsControlledBy(existing, classParams) {
+		return fmt.Errorf("ResourceClassParameters %s/%s isn't generated from GPUClassParameters %s", c.namespace, name, name)
+	}
+
+	desired, err := c.resourceClassParameters(classParams)
+	if err != nil {
+		c.log.Warn().Err(err).Msgf("skipping GPUClassParameters %s", name)
+		if existing == nil {
+			return nil
+		}
+		return c.coreClientSets.ResourceV1alpha2().ResourceClassParameters(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+
+	client := c.coreClientSets.ResourceV1alpha2().ResourceClassParameters(c.namespace)
+	if existing == nil {
+		c.log.Info().Msgf("creating ResourceClassParameters %s/%s", c.namespace, name)
+		_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+
+	if apiequality.Semantic.DeepEqual(existing.GeneratedFrom, desired.GeneratedFrom) &&
+		apiequality.Semantic.DeepEqual(existing.Filters, desired.Filters) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.GeneratedFrom = desired.GeneratedFrom
+	updated.Filters = desired.Filters
+	c.log.Info().Msgf("updating ResourceClassParameters %s/%s", c.namespace, name)
+	_, err = client.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// resourceClaimParameters translates the GPURequirements into
+// ResourceClaimParameters, with one named resources request per requested GPU.
+// Fractional sharing and strict topology policies constrain the allocation
+// beyond what a single device selector can express, so they aren't
+// translatable. Whether the claims are shareable is determined like in the
+// classic controller. See shareable.
+func (c *parametersController) resourceClaimParameters(claimParams *gpuv1alpha1.GPURequirements) (*resourcev1alpha2.ResourceClaimParameters, error) {
+	spec := &claimParams.Spec
+	if err := validateClaimParameters(spec); err != nil {
+		return nil, err
+	}
+
+	if spec.Sharing == gpuv1alpha1.SharingPolicyFractional {
+		return nil, fmt.Errorf("fractional sharing: %w", errUntranslatable)
+	}
+
+	if spec.Topology != "" && spec.Topology != gpuv1alpha1.TopologyPolicyBestEffort {
+		return nil, fmt.Errorf("topology policy %s: %w", spec.Topology, errUntranslatable)
+	}
+
+	shareable, err := c.shareable(claimParams)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := claimSelector(spec)
+	requests := make([]resourcev1alpha2.ResourceRequest, spec.Count)
+	for i := range requests {
+		requests[i].NamedResources = &resourcev1alpha2.NamedResourcesRequest{Selector: selector}
+	}
+
+	return &resourcev1alpha2.ResourceClaimParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claimParams.GetName(),
+			Namespace: claimParams.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(claimParams, gpuv1alpha1.SchemeGroupVersion.WithKind(gpuv1alpha1.GPURequirementsKind)),
+			},
+		},
+		GeneratedFrom: &resourcev1alpha2.ResourceClaimParametersReference{
+			APIGroup: apiGroup,
+			Kind:     gpuv1alpha1.GPURequirementsKind,
+			Name:     claimParams.GetName(),
+		},
+		Shareable: shareable,
+		DriverRequests: []resourcev1alpha2.DriverRequests{
+			{
+				DriverName: driverName,
+				Requests:   requests,
+			},
+		},
+	}, nil
+}
+
+// shareable returns true if the claims of the GPURequirements are shareable.
+// Like in the classic controller, claims that don't specify an access mode use
+// the default access mode of their class, and the claims of time-sliced
+// classes are never shared. The classes are those of the claims that reference
+// the GPURequirements. The GPURequirements aren't translatable if their claims
+// are shareable with some classes but not others. If no claims reference them
+// yet, only their own access mode is used.
+func (c *parametersController) shareable(claimParams *gpuv1alpha1.GPURequirements) (bool, error) {
+	claims, err := c.resourceClaimLister.ResourceClaims(claimParams.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+
+	results := map[bool]bool{}
+	for _, claim := range claims {
+		key, ok := claimParametersKey(claim)
+		if !ok || key.name != claimParams.GetName() {
+			continue
+		}
+
+		classParams, err := c.classParameters(claim.Spec.ResourceClassName)
+		if err != nil {
+			return false, err
+		}
+		if classParams == nil {
+			continue
+		}
+
+		shared, _ := sharing(&claimParams.Spec, classParams)
+		results[shared] = true
+	}
+
+	switch len(results) {
+	case 0:
+		shared, _ := sharing(&claimParams.Spec, &gpuv1alpha1.GPUClassParametersSpec{})
+		return shared, nil
+	case 1:
+		return results[true], nil
+	default:
+		return false, fmt.Errorf("claims are shareable with some classes but not others: %w", errUntranslatable)
+	}
+}
+
+// classParameters returns the GPUClassParameters spec of the class, or the
+// default spec if the class has no parameters. It returns nil if the class
+// doesn't exist or belongs to another driver.
+func (c *parametersController) classParameters(className string) (*gpuv1alpha1.GPUClassParametersSpec, error) {
+	class, err := c.resourceClassLister.Get(className)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if class.DriverName != driverName {
+		return nil, nil
+	}
+
+	if class.ParametersRef == nil {
+		return &gpuv1alpha1.GPUClassParametersSpec{}, nil
+	}
+
+	if class.ParametersRef.APIGroup != apiGroup || class.ParametersRef.Kind != gpuv1alpha1.GPUClassParametersKind {
+		return nil, fmt.Errorf("class %s has unsupported parameters %s/%s", className, class.ParametersRef.APIGroup, class.ParametersRef.Kind)
+	}
+
+	classParams, err := c.classParamsLister.Get(class.ParametersRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &classParams.Spec, nil
+}
+
+// claimSelector returns the CEL selector of the named resources instances
+// that satisfy the GPURequirements. The instance attributes are those published
+// by the kubelet plugin.
+func claimSelector(spec *gpuv1alpha1.GPURequirementsSpec) string {
+	clauses := []string{}
+	if !spec.Memory.IsZero() {
+		clauses = append(clauses, fmt.Sprintf(`attributes.quantity["memory"].compareTo(quantity(%s)) >= 0`, strconv.Quote(spec.Memory.String())))
+	}
+
+	if spec.PartitionProfile != "" {
+		// a missing key is an evaluation error, which fails the whole node
+		clauses = append(clauses, fmt.Sprintf(`"partitionProfile" in attributes.string && attributes.string["partitionProfile"] == %s`, strconv.Quote(spec.PartitionProfile)))
+	} else {
+		clauses = append(clauses, `!("partitionProfile" in attributes.string)`)
+	}
+
+	return strings.Join(clauses, " && ")
+}
+
+// resourceClassParameters translates the GPUClassParameters into
+// ResourceClassParameters, with a named resources filter that matches any of
+// the device selectors. The filter of a time-sliced class only matches the
+// time-sliced replicas advertised by the kubelet plugins with the same number
+// of replicas, and the filter of other classes only matches whole GPUs.
+// Selectors with CEL expressions aren't translatable.
+func (c *parametersController) resourceClassParameters(classParams *gpuv1alpha1.GPUClassParameters) (*resourcev1alpha2.ResourceClassParameters, error) {
+	spec := &classParams.Spec
+	if err := validateClassParameters(c.expressions, spec); err != nil {
+		return nil, err
+	}
+
+	generated := &resourcev1alpha2.ResourceClassParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      classParams.GetName(),
+			Namespace: c.namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(classParams, gpuv1alpha1.SchemeGroupVersion.WithKind(gpuv1alpha1.GPUClassParametersKind)),
+			},
+		},
+		GeneratedFrom: &resourcev1alpha2.ResourceClassParametersReference{
+			APIGroup: apiGroup,
+			Kind:     gpuv1alpha1.GPUClassParametersKind,
+			Name:     classParams.GetName(),
+		},
+	}
+
+	clauses := []string{timeSlicingSelector(timeSlicingReplicas(spec))}
+	if len(spec.DeviceSelector) > 0 {
+		selectors := make([]string, 0, len(spec.DeviceSelector))
+		for _, deviceSelector := range spec.DeviceSelector {
+			selector, err := classSelector(deviceSelector)
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, selector)
+		}
+		clauses = append(clauses, "("+strings.Join(selectors, " || ")+")")
+	}
+
+	generated.Filters = []resourcev1alpha2.ResourceFilter{
+		{
+			DriverName: driverName,
+			ResourceFilterModel: resourcev1alpha2.ResourceFilterModel{
+				NamedResources: &resourcev1alpha2.NamedResourcesFilter{
+					Selector: strings.Join(clauses, " && "),
+				},
+			},
+		},
+	}
+	return generated, nil
+}
+
+// timeSlicingSelector returns the CEL selector of the named resources instances
+// with the number of time-sliced replicas, or of the whole GPUs if replicas is
+// 0. The key is checked first, since a missing key is an evaluation error,
+// which fails the whole node.
+func timeSlicingSelector(replicas int) string {
+	if replicas == 0 {
+		return `!("timeSliceReplicas" in attributes.int)`
+	}
+	return fmt.Sprintf(`"timeSliceReplicas" in attributes.int && attributes.int["timeSliceReplicas"] == %d`, replicas)
+}
+
+// classSelector returns the CEL selector of the named resources instances
+// that match the device selector. The name and vendor glob patterns are
+// translated into regular expressions.
+func classSelector(deviceSelector gpuv1alpha1.DeviceSelector) (string, error) {
+	if deviceSelector.Expression != "" {
+		return "", fmt.Errorf("device selector expression %q: %w", deviceSelector.Expression, errUntranslatable)
+	}
+
+	clauses := []string{}
+	for _, match := range []struct {
+		attribute string
+		pattern   string
+	}{
+		{"productName", deviceSelector.Name},
+		{"vendor", deviceSelector.Vendor},
+	} {
+		if match.pattern == "" || match.pattern == "*" {
+			continue
+		}
+
+		re, err := globToRegexp(match.pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", match.pattern, err)
+		}
+		clauses = append(clauses, fmt.Sprintf(`attributes.string[%s].matches(%s)`, strconv.Quote(match.attribute), strconv.Quote(re)))
+	}
+
+	if len(clauses) == 0 {
+		return "true", nil
+	}
+	return "(" + strings.Join(clauses, " && ") + ")", nil
+}
+
+// globToRegexp translates a glob pattern, with the syntax defined by
+// path.Match, into an anchored RE2 regular expression.
+func globToRegexp(pattern string) (string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '\\':
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			// character classes share the same syntax, except for escapes
+			b.WriteByte('[')
+			for i++; pattern[i] != ']'; i++ {
+				if pattern[i] == '\\' {
+					i++
+					b.WriteString(quoteClassChar(pattern[i]))
+					continue
+				}
+				b.WriteByte(pattern[i])
+			}
+			b.WriteByte(']')
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// quoteClassChar quotes an escaped character of a character class, so that it
+// stands for itself. Unlike QuoteMeta, it quotes the characters that are only
+// special in character classes, like '-'. RE2 allows any punctuation to be
+// escaped.
+func quoteClassChar(c byte) string {
+	if c < utf8.RuneSelf && (unicode.IsPunct(rune(c)) || unicode.IsSymbol(rune(c))) {
+		return `\` + string(c)
+	}
+	return string(c)
+}
@@ -0,0 +1,264 @@
+package gpu
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"testing"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apiserver/pkg/cel/environment"
+	namedresourcescel "k8s.io/dynamic-resource-allocation/structured/namedresources/cel"
+)
+
+// instances are the named resources instances published by the kubelet
+// plugin, with the attributes of a whole GPU, a partition and a time-sliced
+// replica.
+var instances = map[string][]resourcev1alpha2.NamedResourcesAttribute{
+	"whole": gpuAttributes("A100-SXM4-40GB", "nvidia", "40Gi"),
+	"large": gpuAttributes("H100-SXM5-80GB", "nvidia", "80Gi"),
+	"amd":   gpuAttributes("MI300X", "amd", "192Gi"),
+	"partition": gpuAttributes("A100-SXM4-40GB", "nvidia", "10Gi",
+		stringAttribute("parentUUID", "GPU-0"),
+		stringAttribute("partitionProfile", "1g.10gb"),
+		intAttribute("partitionSlices", 1)),
+	"timeSlice": gpuAttributes("A100-SXM4-40GB", "nvidia", "40Gi",
+		intAttribute("timeSliceIndex", 1),
+		intAttribute("timeSliceReplicas", 4)),
+}
+
+func gpuAttributes(productName, vendor, memory string, extra ...resourcev1alpha2.NamedResourcesAttribute) []resourcev1alpha2.NamedResourcesAttribute {
+	quantity := resource.MustParse(memory)
+	return append([]resourcev1alpha2.NamedResourcesAttribute{
+		stringAttribute("uuid", "GPU-0"),
+		stringAttribute("productName", productName),
+		stringAttribute("vendor", vendor),
+		{
+			Name:                         "memory",
+			NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{QuantityValue: &quantity},
+		},
+	}, extra...)
+}
+
+func stringAttribute(name, value string) resourcev1alpha2.NamedResourcesAttribute {
+	return resourcev1alpha2.NamedResourcesAttribute{
+		Name:                         name,
+		NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{StringValue: &value},
+	}
+}
+
+func intAttribute(name string, value int64) resourcev1alpha2.NamedResourcesAttribute {
+	return resourcev1alpha2.NamedResourcesAttribute{
+		Name:                         name,
+		NamedResourcesAttributeValue: resourcev1alpha2.NamedResourcesAttributeValue{IntValue: &value},
+	}
+}
+
+// assertMatches compiles the selector with the CEL compiler of the scheduler,
+// and evaluates it against all the instances. Evaluation errors fail the test,
+// since the scheduler treats them as failures of the whole node.
+func assertMatches(t *testing.T, selector string, want map[string]bool) {
+	t.Helper()
+
+	result := namedresourcescel.Compiler.CompileCELExpression(selector, environment.StoredExpressions)
+	if result.Error != nil {
+		t.Fatalf("failed to compile selector %s: %v", selector, result.Error)
+	}
+
+	for name, attributes := range instances {
+		matched, err := result.Evaluate(context.Background(), attributes)
+		if err != nil {
+			t.Errorf("failed to evaluate selector %s against %s instance: %v", selector, name, err)
+			continue
+		}
+		if matched != want[name] {
+			t.Errorf("selector %s: expected match of %s instance to be %t", selector, name, want[name])
+		}
+	}
+}
+
+func TestClaimSelector(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec gpuv1alpha1.GPURequirementsSpec
+		want map[string]bool
+	}{
+		{
+			name: "no requirements",
+			want: map[string]bool{"whole": true, "large": true, "amd": true, "timeSlice": true},
+		},
+		{
+			name: "memory",
+			spec: gpuv1alpha1.GPURequirementsSpec{Memory: resource.MustParse("64Gi")},
+			want: map[string]bool{"large": true, "amd": true},
+		},
+		{
+			name: "partition profile",
+			spec: gpuv1alpha1.GPURequirementsSpec{PartitionProfile: "1g.10gb"},
+			want: map[string]bool{"partition": true},
+		},
+		{
+			name: "other partition profile",
+			spec: gpuv1alpha1.GPURequirementsSpec{PartitionProfile: "2g.20gb"},
+			want: map[string]bool{},
+		},
+		{
+			name: "partition profile with too much memory",
+			spec: gpuv1alpha1.GPURequirementsSpec{PartitionProfile: "1g.10gb", Memory: resource.MustParse("20Gi")},
+			want: map[string]bool{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertMatches(t, claimSelector(&tc.spec), tc.want)
+		})
+	}
+}
+
+func TestTimeSlicingSelector(t *testing.T) {
+	testCases := []struct {
+		replicas int
+		want     map[string]bool
+	}{
+		{replicas: 0, want: map[string]bool{"whole": true, "large": true, "amd": true, "partition": true}},
+		{replicas: 4, want: map[string]bool{"timeSlice": true}},
+		{replicas: 2, want: map[string]bool{}},
+	}
+
+	for _, tc := range testCases {
+		assertMatches(t, timeSlicingSelector(tc.replicas), tc.want)
+	}
+}
+
+func TestResourceClassParametersFilter(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec gpuv1alpha1.GPUClassParametersSpec
+		want map[string]bool
+	}{
+		{
+			name: "no device selectors",
+			want: map[string]bool{"whole": true, "large": true, "amd": true, "partition": true},
+		},
+		{
+			name: "wildcards",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Name: "*", Vendor: "*"}},
+			},
+			want: map[string]bool{"whole": true, "large": true, "amd": true, "partition": true},
+		},
+		{
+			name: "name pattern",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Name: "A100-*"}},
+			},
+			want: map[string]bool{"whole": true, "partition": true},
+		},
+		{
+			name: "any of the selectors",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Name: "H100-*"}, {Vendor: "amd"}},
+			},
+			want: map[string]bool{"large": true, "amd": true},
+		},
+		{
+			name: "name and vendor",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Name: "?I300[WX]", Vendor: "amd"}},
+			},
+			want: map[string]bool{"amd": true},
+		},
+		{
+			name: "escaped characters in classes",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Name: `A100[\-]SXM4*`}},
+			},
+			want: map[string]bool{"whole": true, "partition": true},
+		},
+		{
+			name: "time-sliced",
+			spec: gpuv1alpha1.GPUClassParametersSpec{
+				DeviceSelector: []gpuv1alpha1.DeviceSelector{{Vendor: "nvidia"}},
+				TimeSlicing:    &gpuv1alpha1.TimeSlicing{Replicas: 4},
+			},
+			want: map[string]bool{"timeSlice": true},
+		},
+	}
+
+	c := &parametersController{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generated, err := c.resourceClassParameters(&gpuv1alpha1.GPUClassParameters{Spec: tc.spec})
+			if err != nil {
+				t.Fatalf("failed to translate class parameters: %v", err)
+			}
+			if len(generated.Filters) != 1 {
+				t.Fatalf("expected 1 filter, got %d", len(generated.Filters))
+			}
+			assertMatches(t, generated.Filters[0].NamedResources.Selector, tc.want)
+		})
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		invalid bool
+	}{
+		{pattern: "*"},
+		{pattern: "A100*"},
+		{pattern: "A?00"},
+		{pattern: "[AB]100"},
+		{pattern: "[^A]100"},
+		{pattern: "[a-c]*"},
+		{pattern: `[a\-c]`},
+		{pattern: `[\]]`},
+		{pattern: `[\\]`},
+		{pattern: `[\^a]`},
+		{pattern: `\[x`},
+		{pattern: `a\*`},
+		{pattern: `\\`},
+		{pattern: "x]"},
+		{pattern: "a.b+(c)|d$"},
+		{pattern: "[", invalid: true},
+		{pattern: `\`, invalid: true},
+		{pattern: "[a", invalid: true},
+		{pattern: `[\`, invalid: true},
+	}
+
+	candidates := []string{
+		"", "A100", "B100", "C100", "a", "b", "c", "-", "]", "\\", "^", "[x", "a*", "ab",
+		"x]", "a.b+(c)|d$", "axb+(c)|d$", "A1/0", "A100/x",
+	}
+
+	for _, tc := range testCases {
+		re, err := globToRegexp(tc.pattern)
+		if tc.invalid {
+			if err == nil {
+				t.Errorf("expected pattern %q to be invalid, got %s", tc.pattern, re)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to translate pattern %q: %v", tc.pattern, err)
+			continue
+		}
+
+		compiled, err := regexp.Compile(re)
+		if err != nil {
+			t.Errorf("pattern %q translated into invalid regular expression %s: %v", tc.pattern, re, err)
+			continue
+		}
+
+		// the translation must match the same values as path.Match
+		for _, candidate := range candidates {
+			want, _ := path.Match(tc.pattern, candidate)
+			if got := compiled.MatchString(candidate); got != want {
+				t.Errorf("pattern %q (%s): expected match of %q to be %t", tc.pattern, re, candidate, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gpu
+
+import (
+	"sort"
+	"strconv"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+)
+
+// topologyKey returns the topology domain of a GPU, and false if the GPU's
+// domain is unknown.
+type topologyKey func(*availableGPU) (string, bool)
+
+func numaNode(gpu *availableGPU) (string, bool) {
+	if gpu.Topology == nil || gpu.Topology.NUMANode == nil {
+		return "", false
+	}
+	return strconv.Itoa(*gpu.Topology.NUMANode), true
+}
+
+func interconnectGroup(gpu *availableGPU) (string, bool) {
+	if gpu.Topology == nil || gpu.Topology.InterconnectGroup == "" {
+		return "", false
+	}
+	return gpu.Topology.InterconnectGroup, true
+}
+
+// selectGPUs returns count GPUs out of the candidates that satisfy the
+// topology policy, or nil if there aren't enough of them. GPUs whose topology
+// is unknown never satisfy the SameNUMA and SameInterconnectGroup policies.
+// With the BestEffort policy, GPUs that share an interconnect group are
+// preferred over GPUs that share a NUMA node, which in turn are preferred over
+// any GPUs. In all cases, the GPUs held for the claim are preferred, followed
+// by the order of the candidates.
+func selectGPUs(
+	candidates []*availableGPU,
+	held map[string]bool,
+	count int,
+	policy gpuv1alpha1.TopologyPolicy) []*availableGPU {
+	// move the held GPUs first, keeping the order of the candidates otherwise
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return held[candidates[i].UUID] && !held[candidates[j].UUID]
+	})
+
+	switch policy {
+	case gpuv1alpha1.TopologyPolicySameNUMA:
+		return selectFromDomains(candidates, held, count, numaNode)
+	case gpuv1alpha1.TopologyPolicySameInterconnectGroup:
+		return selectFromDomains(candidates, held, count, interconnectGroup)
+	}
+
+	if selected := selectFromDomains(candidates, held, count, interconnectGroup); selected != nil {
+		return selected
+	}
+
+	if selected := selectFromDomains(candidates, held, count, numaNode); selected != nil {
+		return selected
+	}
+
+	if len(candidates) < count {
+		return nil
+	}
+	return candidates[:count]
+}
+
+// selectFromDomains groups the candidates by their topology domain, and returns
+// count GPUs from a single domain. The domain with the most held GPUs is
+// chosen, followed by the smallest domain that fits, so that larger domains are
+// kept free for claims that need them. It returns nil if no domain has enough
+// GPUs.
+func selectFromDomains(
+	candidates []*availableGPU,
+	held map[string]bool,
+	count int,
+	key topologyKey) []*availableGPU {
+	domains := map[string][]*availableGPU{}
+	for _, gpu := range candidates {
+		if domain, known := key(gpu); known {
+			domains[domain] = append(domains[domain], gpu)
+		}
+	}
+
+	var (
+		selected     string
+		selectedHeld int
+	)
+	for domain, gpus := range domains {
+		if len(gpus) < count {
+			continue
+		}
+
+		heldCount := 0
+		for _, gpu := range gpus {
+			if held[gpu.UUID] {
+				heldCount++
+			}
+		}
+
+		switch {
+		case selected == "":
+		case heldCount != selectedHeld:
+			if heldCount < selectedHeld {
+				continue
+			}
+		case len(gpus) != len(domains[selected]):
+			if len(gpus) > len(domains[selected]) {
+				continue
+			}
+		case domain > selected:
+			continue
+		}
+		selected, selectedHeld = domain, heldCount
+	}
+
+	if selected == "" {
+		return nil
+	}
+	return domains[selected][:count]
+}
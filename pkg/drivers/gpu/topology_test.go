@@ -0,0 +1,266 @@
+package gpu
+
+import (
+	"testing"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	gpuv1alpha2 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha2"
+)
+
+// topologyGPU returns an available GPU on the NUMA node and interconnect group.
+// A negative NUMA node and an empty group are unknown.
+func topologyGPU(uuid string, numaNode int, interconnectGroup string) *availableGPU {
+	gpu := &availableGPU{GPUDevice: gpuDevice(uuid, "40Gi")}
+	if numaNode < 0 && interconnectGroup == "" {
+		return gpu
+	}
+
+	gpu.Topology = &gpuv1alpha2.GPUTopology{InterconnectGroup: interconnectGroup}
+	if numaNode >= 0 {
+		gpu.Topology.NUMANode = &numaNode
+	}
+	return gpu
+}
+
+// topologyCandidates returns GPUs that span two NUMA nodes and three
+// interconnect groups:
+//
+//	NUMA node 0: GPU-0 (nvl-a), GPU-1 (nvl-a), GPU-2 (nvl-b)
+//	NUMA node 1: GPU-3 (nvl-b), GPU-4 (nvl-b), GPU-5 (nvl-c), GPU-6
+//	unknown:     GPU-7
+func topologyCandidates() []*availableGPU {
+	return []*availableGPU{
+		topologyGPU("GPU-0", 0, "nvl-a"),
+		topologyGPU("GPU-1", 0, "nvl-a"),
+		topologyGPU("GPU-2", 0, "nvl-b"),
+		topologyGPU("GPU-3", 1, "nvl-b"),
+		topologyGPU("GPU-4", 1, "nvl-b"),
+		topologyGPU("GPU-5", 1, "nvl-c"),
+		topologyGPU("GPU-6", 1, ""),
+		topologyGPU("GPU-7", -1, ""),
+	}
+}
+
+func assertSelected(t *testing.T, selected []*availableGPU, want []string) {
+	t.Helper()
+
+	if want == nil {
+		if selected != nil {
+			t.Errorf("expected no GPUs to be selected, got %d", len(selected))
+		}
+		return
+	}
+
+	if len(selected) != len(want) {
+		t.Fatalf("expected %d GPUs, got %d", len(want), len(selected))
+	}
+	for i, gpu := range selected {
+		if gpu.UUID != want[i] {
+			t.Errorf("expected GPU %s at %d, got %s", want[i], i, gpu.UUID)
+		}
+	}
+}
+
+func TestSelectGPUs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		candidates []*availableGPU
+		held       []string
+		count      int
+		policy     gpuv1alpha1.TopologyPolicy
+
+		// nil if the node must be rejected
+		want []string
+	}{
+		{
+			name:   "same NUMA node, smallest that fits",
+			count:  3,
+			policy: gpuv1alpha1.TopologyPolicySameNUMA,
+			want:   []string{"GPU-0", "GPU-1", "GPU-2"},
+		},
+		{
+			name:   "same NUMA node, only one that fits",
+			count:  4,
+			policy: gpuv1alpha1.TopologyPolicySameNUMA,
+			want:   []string{"GPU-3", "GPU-4", "GPU-5", "GPU-6"},
+		},
+		{
+			name:   "same NUMA node with held GPU",
+			held:   []string{"GPU-4"},
+			count:  2,
+			policy: gpuv1alpha1.TopologyPolicySameNUMA,
+			want:   []string{"GPU-4", "GPU-3"},
+		},
+		{
+			name:   "no NUMA node that fits",
+			count:  5,
+			policy: gpuv1alpha1.TopologyPolicySameNUMA,
+		},
+		{
+			name: "unknown NUMA nodes",
+			candidates: []*availableGPU{
+				topologyGPU("GPU-0", -1, "nvl-a"),
+				topologyGPU("GPU-1", -1, ""),
+			},
+			count:  1,
+			policy: gpuv1alpha1.TopologyPolicySameNUMA,
+		},
+		{
+			name:   "same interconnect group, smallest that fits",
+			count:  1,
+			policy: gpuv1alpha1.TopologyPolicySameInterconnectGroup,
+			want:   []string{"GPU-5"},
+		},
+		{
+			name:   "same interconnect group across NUMA nodes",
+			count:  3,
+			policy: gpuv1alpha1.TopologyPolicySameInterconnectGroup,
+			want:   []string{"GPU-2", "GPU-3", "GPU-4"},
+		},
+		{
+			name:   "same interconnect group with held GPU",
+			held:   []string{"GPU-3"},
+			count:  2,
+			policy: gpuv1alpha1.TopologyPolicySameInterconnectGroup,
+			want:   []string{"GPU-3", "GPU-2"},
+		},
+		{
+			name:   "no interconnect group that fits",
+			count:  4,
+			policy: gpuv1alpha1.TopologyPolicySameInterconnectGroup,
+		},
+		{
+			name: "unknown interconnect groups",
+			candidates: []*availableGPU{
+				topologyGPU("GPU-0", 0, ""),
+				topologyGPU("GPU-1", -1, ""),
+			},
+			count:  1,
+			policy: gpuv1alpha1.TopologyPolicySameInterconnectGroup,
+		},
+		{
+			name:   "best effort prefers interconnect group",
+			count:  2,
+			policy: gpuv1alpha1.TopologyPolicyBestEffort,
+			want:   []string{"GPU-0", "GPU-1"},
+		},
+		{
+			name:   "best effort falls back to NUMA node",
+			count:  4,
+			policy: gpuv1alpha1.TopologyPolicyBestEffort,
+			want:   []string{"GPU-3", "GPU-4", "GPU-5", "GPU-6"},
+		},
+		{
+			name:   "best effort falls back to any GPUs",
+			count:  6,
+			policy: gpuv1alpha1.TopologyPolicyBestEffort,
+			want:   []string{"GPU-0", "GPU-1", "GPU-2", "GPU-3", "GPU-4", "GPU-5"},
+		},
+		{
+			name:   "best effort with held GPUs across domains",
+			held:   []string{"GPU-7", "GPU-0"},
+			count:  6,
+			policy: gpuv1alpha1.TopologyPolicyBestEffort,
+			want:   []string{"GPU-0", "GPU-7", "GPU-1", "GPU-2", "GPU-3", "GPU-4"},
+		},
+		{
+			name:   "best effort with insufficient GPUs",
+			count:  9,
+			policy: gpuv1alpha1.TopologyPolicyBestEffort,
+		},
+		{
+			name:  "no policy",
+			count: 3,
+			want:  []string{"GPU-2", "GPU-3", "GPU-4"},
+		},
+		{
+			name: "no policy with unknown topology",
+			candidates: []*availableGPU{
+				topologyGPU("GPU-0", -1, ""),
+				topologyGPU("GPU-1", -1, ""),
+			},
+			count: 2,
+			want:  []string{"GPU-0", "GPU-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			candidates := tc.candidates
+			if candidates == nil {
+				candidates = topologyCandidates()
+			}
+
+			held := map[string]bool{}
+			for _, uuid := range tc.held {
+				held[uuid] = true
+			}
+
+			assertSelected(t, selectGPUs(candidates, held, tc.count, tc.policy), tc.want)
+		})
+	}
+}
+
+func TestSelectFromDomains(t *testing.T) {
+	testCases := []struct {
+		name  string
+		held  []string
+		count int
+		key   topologyKey
+		want  []string
+	}{
+		{
+			name:  "domains of the same size",
+			count: 2,
+			key:   interconnectGroup,
+			want:  []string{"GPU-0", "GPU-1"},
+		},
+		{
+			name:  "domain with the most held GPUs",
+			held:  []string{"GPU-1", "GPU-2", "GPU-3"},
+			count: 2,
+			key:   numaNode,
+			want:  []string{"GPU-2", "GPU-3"},
+		},
+		{
+			name:  "held GPUs in a domain that doesn't fit",
+			held:  []string{"GPU-4"},
+			count: 2,
+			key:   numaNode,
+			want:  []string{"GPU-0", "GPU-1"},
+		},
+		{
+			name:  "smallest domain",
+			count: 1,
+			key:   interconnectGroup,
+			want:  []string{"GPU-4"},
+		},
+		{
+			name:  "no domain that fits",
+			count: 3,
+			key:   numaNode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// NUMA node 0: GPU-0 (nvl-a), GPU-1 (nvl-a)
+			// NUMA node 1: GPU-2 (nvl-b), GPU-3 (nvl-b)
+			// NUMA node 2: GPU-4 (nvl-c)
+			candidates := []*availableGPU{
+				topologyGPU("GPU-0", 0, "nvl-a"),
+				topologyGPU("GPU-1", 0, "nvl-a"),
+				topologyGPU("GPU-2", 1, "nvl-b"),
+				topologyGPU("GPU-3", 1, "nvl-b"),
+				topologyGPU("GPU-4", 2, "nvl-c"),
+			}
+
+			held := map[string]bool{}
+			for _, uuid := range tc.held {
+				held[uuid] = true
+			}
+
+			assertSelected(t, selectFromDomains(candidates, held, tc.count, tc.key), tc.want)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+)
+
+// validateClassParameters returns an error if any of the device selectors is
+// empty, has a malformed name or vendor pattern, or has an expression that
+// doesn't compile, or if the sharing parameters are invalid. All the
+// violations are joined into the returned error.
+func validateClassParameters(expressions *expressionEvaluator, classParams *gpuv1alpha1.GPUClassParametersSpec) error {
+	var errs error
+	for i, selector := range classParams.DeviceSelector {
+		if selector.Name == "" && selector.Vendor == "" && selector.Expression == "" {
+			errs = errors.Join(errs, fmt.Errorf("deviceSelector[%d]: at least one of name, vendor or expression must be set", i))
+			continue
+		}
+
+		if _, err := path.Match(selector.Name, ""); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("deviceSelector[%d]: invalid name pattern %q: %w", i, selector.Name, err))
+		}
+
+		if _, err := path.Match(selector.Vendor, ""); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("deviceSelector[%d]: invalid vendor pattern %q: %w", i, selector.Vendor, err))
+		}
+
+		if selector.Expression != "" {
+			if _, err := expressions.compile(selector.Expression); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("deviceSelector[%d]: invalid expression: %w", i, err))
+			}
+		}
+	}
+
+	if !validAccessMode(classParams.DefaultAccessMode) {
+		errs = errors.Join(errs, fmt.Errorf("invalid default access mode: %s", classParams.DefaultAccessMode))
+	}
+
+	if classParams.MaxSharers < 0 {
+		errs = errors.Join(errs, fmt.Errorf("invalid maximum number of sharers: %d", classParams.MaxSharers))
+	}
+
+	if classParams.TimeSlicing != nil && classParams.TimeSlicing.Replicas < 1 {
+		errs = errors.Join(errs, fmt.Errorf("invalid number of time-sliced replicas: %d", classParams.TimeSlicing.Replicas))
+	}
+
+	return errs
+}
+
+// validateClaimParameters returns an error if the claim requests less than one
+// GPU, a negative amount of memory, an unknown topology policy, access mode or
+// sharing policy, or a fractional claim without memory or with the Shared
+// access mode.
+func validateClaimParameters(claimParams *gpuv1alpha1.GPURequirementsSpec) error {
+	var errs error
+	if claimParams.Count < 1 {
+		errs = errors.Join(errs, fmt.Errorf("invalid number of GPUs requested: %v", claimParams.Count))
+	}
+
+	if claimParams.Memory.Sign() < 0 {
+		errs = errors.Join(errs, fmt.Errorf("invalid amount of memory requested: %s", claimParams.Memory.String()))
+	}
+
+	switch claimParams.Topology {
+	case "", gpuv1alpha1.TopologyPolicySameNUMA, gpuv1alpha1.TopologyPolicySameInterconnectGroup, gpuv1alpha1.TopologyPolicyBestEffort:
+	default:
+		errs = errors.Join(errs, fmt.Errorf("invalid topology policy: %s", claimParams.Topology))
+	}
+
+	if !validAccessMode(claimParams.AccessMode) {
+		errs = errors.Join(errs, fmt.Errorf("invalid access mode: %s", claimParams.AccessMode))
+	}
+
+	switch claimParams.Sharing {
+	case "":
+	case gpuv1alpha1.SharingPolicyFractional:
+		if claimParams.Memory.Sign() <= 0 {
+			errs = errors.Join(errs, fmt.Errorf("fractional sharing requires memory to be requested"))
+		}
+
+		if claimParams.AccessMode == gpuv1alpha1.AccessModeShared {
+			errs = errors.Join(errs, fmt.Errorf("fractional sharing can't be used with the %s access mode", claimParams.AccessMode))
+		}
+	default:
+		errs = errors.Join(errs, fmt.Errorf("invalid sharing policy: %s", claimParams.Sharing))
+	}
+
+	return errs
+}
+
+// validAccessMode returns true if the access mode is either empty, Exclusive or
+// Shared.
+func validAccessMode(accessMode gpuv1alpha1.AccessMode) bool {
+	switch accessMode {
+	case "", gpuv1alpha1.AccessModeExclusive, gpuv1alpha1.AccessModeShared:
+		return true
+	}
+	return false
+}
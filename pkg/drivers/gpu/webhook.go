@@ -0,0 +1,115 @@
+package gpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	gpuv1alpha1 "github.com/ihcsim/k8s-dra/pkg/apis/gpu/v1alpha1"
+	zlog "github.com/rs/zerolog"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const maxAdmissionReviewBytes = 1 << 20
+
+// webhook implements a validating admission webhook that rejects invalid
+// GPURequirements and GPUClassParameters objects at create and update time.
+type webhook struct {
+	expressions *expressionEvaluator
+	log         zlog.Logger
+}
+
+// NewWebhook returns a new instance of the validating admission webhook.
+func NewWebhook(log zlog.Logger) (*webhook, error) {
+	expressions, err := newExpressionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook{
+		expressions: expressions,
+		log:         log,
+	}, nil
+}
+
+// ServeHTTP handles the AdmissionReview requests sent by the API server.
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, fmt.Sprintf("unsupported method %s", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(http.MaxBytesReader(rw, req.Body, maxAdmissionReviewBytes)).Decode(review); err != nil {
+		http.Error(rw, fmt.Sprintf("failed to decode admission review: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if review.Request == nil {
+		http.Error(rw, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.review(review.Request)
+	review.Request = nil
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		w.log.Error().Err(err).Msg("failed to encode admission review")
+	}
+}
+
+func (w *webhook) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	log := w.log.With().
+		Str("uid", string(req.UID)).
+		Str("kind", req.Kind.Kind).
+		Str("namespace", req.Namespace).
+		Str("name", req.Name).
+		Str("operation", string(req.Operation)).
+		Logger()
+
+	res := &admissionv1.AdmissionResponse{
+		UID:     req.UID,
+		Allowed: true,
+	}
+
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return res
+	}
+
+	if err := w.validate(req); err != nil {
+		log.Info().Err(err).Msg("rejecting invalid object")
+		res.Allowed = false
+		res.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		}
+		return res
+	}
+
+	log.Debug().Msg("admitting object")
+	return res
+}
+
+func (w *webhook) validate(req *admissionv1.AdmissionRequest) error {
+	switch req.Kind.Kind {
+	case gpuv1alpha1.GPURequirementsKind:
+		claimParams := &gpuv1alpha1.GPURequirements{}
+		if err := json.Unmarshal(req.Object.Raw, claimParams); err != nil {
+			return fmt.Errorf("failed to decode GPURequirements: %w", err)
+		}
+		return validateClaimParameters(&claimParams.Spec)
+
+	case gpuv1alpha1.GPUClassParametersKind:
+		classParams := &gpuv1alpha1.GPUClassParameters{}
+		if err := json.Unmarshal(req.Object.Raw, classParams); err != nil {
+			return fmt.Errorf("failed to decode GPUClassParameters: %w", err)
+		}
+		return validateClassParameters(w.expressions, &classParams.Spec)
+	}
+
+	return fmt.Errorf("unsupported kind %s", req.Kind.Kind)
+}